@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CLI configuration constants
+const (
+	// DefaultReleaseTimeout is how long to wait for a port to be released after killing its holders
+	DefaultReleaseTimeout = 10 * time.Second
+
+	// ReleasePollInterval is how often the scanner is polled while waiting for a port to be released
+	ReleasePollInterval = 200 * time.Millisecond
+)
+
+// errAborted is returned when the user declines a confirmation prompt
+var errAborted = errors.New("aborted")
+
+// killPolicy controls whether processes holding a port are killed
+type killPolicy string
+
+const (
+	killPolicyAsk    killPolicy = "ask"    // prompt before killing
+	killPolicyAlways killPolicy = "always" // kill without asking
+	killPolicyNever  killPolicy = "never"  // never kill, fail instead
+)
+
+// String implements flag.Value
+func (k *killPolicy) String() string {
+	return string(*k)
+}
+
+// Set implements flag.Value
+func (k *killPolicy) Set(value string) error {
+	switch killPolicy(value) {
+	case killPolicyAsk, killPolicyAlways, killPolicyNever:
+		*k = killPolicy(value)
+		return nil
+	}
+	return fmt.Errorf("must be one of ask, always, never")
+}
+
+// portRange is an inclusive range of TCP ports
+type portRange struct {
+	lo, hi int
+}
+
+// contains reports whether port lies within the range
+func (r portRange) contains(port int) bool {
+	return port >= r.lo && port <= r.hi
+}
+
+// String formats the range the same way parsePortRange accepts it
+func (r portRange) String() string {
+	if r.lo == r.hi {
+		return strconv.Itoa(r.lo)
+	}
+	return fmt.Sprintf("%d-%d", r.lo, r.hi)
+}
+
+// parsePortRange parses a single port ("3000") or an inclusive range ("4000-4999")
+func parsePortRange(s string) (portRange, error) {
+	loStr, hiStr, isRange := strings.Cut(s, "-")
+	if !isRange {
+		hiStr = loStr
+	}
+
+	lo, err := parsePortNumber(loStr)
+	if err != nil {
+		return portRange{}, fmt.Errorf("invalid port range %q: %w", s, err)
+	}
+	hi, err := parsePortNumber(hiStr)
+	if err != nil {
+		return portRange{}, fmt.Errorf("invalid port range %q: %w", s, err)
+	}
+	if lo > hi {
+		return portRange{}, fmt.Errorf("invalid port range %q: start is after end", s)
+	}
+	return portRange{lo: lo, hi: hi}, nil
+}
+
+// parsePortNumber parses a port number between 1 and 65535
+func parsePortNumber(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %d is out of range", port)
+	}
+	return port, nil
+}
+
+// processLabel returns a short human readable label for a process,
+// preferring the formatted command over the bare process name.
+func processLabel(p Process) string {
+	if formatted := formatCommand(p.Command); formatted != "" {
+		return formatted
+	}
+	return p.Name
+}
+
+// holdersOf returns the processes currently listening on the given port
+func holdersOf(scanner PortScanner, port int) ([]Process, error) {
+	processes, err := scanner.GetListeningPorts()
+	if err != nil {
+		return nil, err
+	}
+
+	var holders []Process
+	for _, p := range processes {
+		for _, pPort := range p.Ports {
+			if pPort == port {
+				holders = append(holders, p)
+				break
+			}
+		}
+	}
+	return holders, nil
+}
+
+// clearPort kills every process holding the port according to the kill policy
+// and waits until the port has been released. The confirm function is used to
+// ask the user when the policy is killPolicyAsk; every holder is confirmed
+// before any of them is killed.
+func clearPort(scanner PortScanner, killer ProcessKiller, port int, policy killPolicy, timeout time.Duration, confirm func(question string) bool) error {
+	holders, err := killHolders(scanner, killer, port, policy, confirm)
+	if err != nil || len(holders) == 0 {
+		return err
+	}
+	return waitForRelease(scanner, port, timeout)
+}
+
+// killHolders kills every process holding the port according to the kill
+// policy, without waiting for the port to be released, and returns them
+func killHolders(scanner PortScanner, killer ProcessKiller, port int, policy killPolicy, confirm func(question string) bool) ([]Process, error) {
+	holders, err := holdersOf(scanner, port)
+	if err != nil {
+		return nil, fmt.Errorf("scanning ports: %w", err)
+	}
+
+	for _, p := range holders {
+		switch policy {
+		case killPolicyNever:
+			return nil, fmt.Errorf("port %d is held by %s (pid %d)", port, processLabel(p), p.PID)
+		case killPolicyAsk:
+			question := fmt.Sprintf("port %d held by %s (pid %d), kill it with %s?", port, processLabel(p), p.PID, killDescription(killer, p))
+			if !confirm(question) {
+				return nil, errAborted
+			}
+		}
+	}
+	for _, p := range holders {
+		if err := sendKill(killer, killTarget{Process: p}); !killSent(err) {
+			return nil, fmt.Errorf("killing process %d: %w", p.PID, err)
+		} else if err != nil {
+			eprintf("process %d: %v", p.PID, err)
+		}
+	}
+	return holders, nil
+}
+
+// waitForRelease polls the scanner until nothing is listening on the port
+// or the timeout expires.
+func waitForRelease(scanner PortScanner, port int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		holders, err := holdersOf(scanner, port)
+		if err != nil {
+			return fmt.Errorf("scanning ports: %w", err)
+		}
+		if len(holders) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("port %d still held by pid %d after %s", port, holders[0].PID, timeout)
+		}
+		time.Sleep(ReleasePollInterval)
+	}
+}
+
+// promptConfirm asks a yes/no question on stderr and reads the answer from stdin.
+// Anything other than "y" or "yes" counts as no.
+func promptConfirm(question string) bool {
+	return readConfirm(os.Stdin, os.Stderr, question)
+}
+
+// readConfirm asks a yes/no question on w and reads the answer from r
+func readConfirm(r io.Reader, w io.Writer, question string) bool {
+	fmt.Fprintf(w, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(w)
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// eprintf prints a message prefixed with the program name to stderr
+func eprintf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "portsweep: "+format+"\n", args...)
+}
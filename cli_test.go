@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// SequenceScanner implements PortScanner, returning a different result on each call.
+// The last result is repeated once the sequence is exhausted.
+type SequenceScanner struct {
+	Results [][]Process
+	calls   int
+}
+
+func (s *SequenceScanner) GetListeningPorts() ([]Process, error) {
+	i := min(s.calls, len(s.Results)-1)
+	s.calls++
+	return s.Results[i], nil
+}
+
+func TestHoldersOf(t *testing.T) {
+	scanner := &MockScanner{
+		Processes: []Process{
+			{PID: 1, Ports: []int{3000, 3001}, Name: "node"},
+			{PID: 2, Ports: []int{8080}, Name: "nginx"},
+			{PID: 3, Ports: []int{30000}, Name: "other"},
+		},
+	}
+
+	holders, err := holdersOf(scanner, 3001)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(holders) != 1 || holders[0].PID != 1 {
+		t.Errorf("expected holder PID 1, got %v", holders)
+	}
+
+	holders, err = holdersOf(scanner, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(holders) != 0 {
+		t.Errorf("expected no holders, got %v", holders)
+	}
+
+	scanner.Err = errors.New("lsof failed")
+	if _, err := holdersOf(scanner, 3000); err == nil {
+		t.Error("expected scanner error to be returned")
+	}
+}
+
+func TestClearPort(t *testing.T) {
+	held := []Process{{PID: 42, Ports: []int{3000}, Name: "node"}}
+	always := func(string) bool { return true }
+	never := func(string) bool { return false }
+	twoHeld := []Process{held[0], {PID: 43, Ports: []int{3000}, Name: "node"}}
+	// firstOnly confirms the first question and declines the rest
+	firstOnly := func() func(string) bool {
+		asked := 0
+		return func(string) bool {
+			asked++
+			return asked == 1
+		}
+	}
+
+	tests := []struct {
+		name       string
+		policy     killPolicy
+		confirm    func(string) bool
+		results    [][]Process
+		wantErr    bool
+		wantKilled []int
+	}{
+		{"port already free", killPolicyAsk, never, [][]Process{{}}, false, nil},
+		{"always kills", killPolicyAlways, never, [][]Process{held, {}}, false, []int{42}},
+		{"ask confirmed", killPolicyAsk, always, [][]Process{held, {}}, false, []int{42}},
+		{"ask declined", killPolicyAsk, never, [][]Process{held}, true, nil},
+		{"never refuses", killPolicyNever, always, [][]Process{held}, true, nil},
+		{"not released", killPolicyAlways, always, [][]Process{held}, true, []int{42}},
+		{"second holder declined", killPolicyAsk, firstOnly(), [][]Process{twoHeld}, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := &SequenceScanner{Results: tt.results}
+			killer := &MockKiller{}
+
+			err := clearPort(scanner, killer, 3000, tt.policy, 0, tt.confirm)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("clearPort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(killer.KilledPIDs) != len(tt.wantKilled) {
+				t.Fatalf("expected killed %v, got %v", tt.wantKilled, killer.KilledPIDs)
+			}
+			for i, pid := range tt.wantKilled {
+				if killer.KilledPIDs[i] != pid {
+					t.Errorf("expected killed %v, got %v", tt.wantKilled, killer.KilledPIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestKillHoldersDryRun(t *testing.T) {
+	scanner := &MockScanner{Processes: []Process{{PID: 42, Ports: []int{3000}, Name: "postgres"}}}
+	killer := &RecordingKiller{Rehearsed: &SignalKiller{Signal: syscall.SIGINT}}
+
+	var question string
+	holders, err := killHolders(scanner, killer, 3000, killPolicyAsk, func(q string) bool {
+		question = q
+		return true
+	})
+	if err != nil || len(holders) != 1 {
+		t.Fatalf("killHolders() = %v, %v", holders, err)
+	}
+	if got := killer.Killed(); len(got) != 1 || got[0] != 42 {
+		t.Errorf("expected PID 42 to be recorded, got %v", got)
+	}
+	if !strings.Contains(question, "SIGINT") {
+		t.Errorf("expected the question to name the rehearsed signal, got %q", question)
+	}
+}
+
+func TestKillPolicySet(t *testing.T) {
+	var k killPolicy
+	for _, valid := range []string{"ask", "always", "never"} {
+		if err := k.Set(valid); err != nil {
+			t.Errorf("Set(%q) returned error: %v", valid, err)
+		}
+		if string(k) != valid {
+			t.Errorf("Set(%q) stored %q", valid, k)
+		}
+	}
+	if err := k.Set("sometimes"); err == nil {
+		t.Error("expected error for invalid policy")
+	}
+}
+
+func TestReadConfirm(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		var out strings.Builder
+		if got := readConfirm(strings.NewReader(tt.input), &out, "kill it?"); got != tt.expected {
+			t.Errorf("readConfirm(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+		if !strings.HasPrefix(out.String(), "kill it? [y/N]") {
+			t.Errorf("unexpected prompt %q", out.String())
+		}
+	}
+}
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    portRange
+		wantErr bool
+	}{
+		{"3000", portRange{3000, 3000}, false},
+		{"4000-4999", portRange{4000, 4999}, false},
+		{"1-65535", portRange{1, 65535}, false},
+		{"4999-4000", portRange{}, true},
+		{"0-100", portRange{}, true},
+		{"3000-70000", portRange{}, true},
+		{"abc", portRange{}, true},
+		{"3000-", portRange{}, true},
+		{"", portRange{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePortRange(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePortRange(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePortRange(%q) = %v, expected %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// columnID names a column of the process list
+type columnID string
+
+const (
+	colPort    columnID = "port"
+	colPID     columnID = "pid"
+	colProcess columnID = "process"
+	colUser    columnID = "user"
+	colCommand columnID = "command"
+	colAddress columnID = "addr"
+	colProto   columnID = "proto"
+	colPPID    columnID = "ppid"
+	colUptime  columnID = "uptime"
+	colCPU     columnID = "cpu"
+	colMemory  columnID = "mem"
+	colCwd     columnID = "cwd"
+	colProject columnID = "project"
+	colConns   columnID = "conns"
+)
+
+// defaultColumns are the columns shown unless others were chosen
+var defaultColumns = []columnID{colPort, colPID, colProcess, colUser, colCommand}
+
+// checkboxWidth is the width of the checkbox and space in front of every row
+const checkboxWidth = 4
+
+// column describes a column of the process list
+type column struct {
+	id        columnID
+	title     string
+	desc      string // what the column shows, for the column picker
+	minWidth  int    // narrowest the column gets on a narrow terminal
+	maxWidth  int    // widest the column gets; 0 for columns that take the remaining space
+	style     lipgloss.Style
+	sort      sortColumn // the sort column marked in the header, "" if none
+	highlight bool       // whether fuzzy matches in fuzzy are highlighted
+	fuzzy     fuzzyField
+	value     func(r columnRow) string
+}
+
+// columnRow is what the values of a row are computed from
+type columnRow struct {
+	Process
+	info   procInfo      // zero if the process is missing from the process table
+	extras processExtras // zero apart from Conns -1 if not gathered
+	now    time.Time
+}
+
+// columnCatalog lists every column, in the order the column picker offers them
+var columnCatalog = []column{
+	{id: colPort, title: "PORT", desc: "listening ports", minWidth: 5, maxWidth: 18, style: portStyle, sort: sortPort,
+		highlight: true, fuzzy: fuzzyPort, value: func(r columnRow) string { return formatPorts(r.Ports, 18) }},
+	{id: colPID, title: "PID", desc: "process ID", minWidth: 3, maxWidth: 8, style: pidStyle, sort: sortPID,
+		value: func(r columnRow) string { return strconv.Itoa(r.PID) }},
+	{id: colProcess, title: "PROCESS", desc: "process name", minWidth: 4, maxWidth: 15, style: nameStyle, sort: sortName,
+		highlight: true, fuzzy: fuzzyName, value: func(r columnRow) string { return r.Name }},
+	{id: colUser, title: "USER", desc: "owner", minWidth: 4, maxWidth: 12, style: userStyle, sort: sortUser,
+		value: func(r columnRow) string { return r.User }},
+	{id: colCommand, title: "COMMAND", desc: "formatted command", minWidth: 10, style: commandStyle, sort: sortCommand,
+		highlight: true, fuzzy: fuzzyCommand, value: func(r columnRow) string { return formatCommand(r.Command) }},
+	{id: colAddress, title: "ADDRESS", desc: "bind addresses", minWidth: 4, maxWidth: 24, style: addrStyle,
+		value: func(r columnRow) string { return listenerAddresses(r.Listeners) }},
+	{id: colProto, title: "PROTO", desc: "tcp4 or tcp6", minWidth: 4, maxWidth: 9, style: addrStyle,
+		value: func(r columnRow) string { return listenerProtocols(r.Listeners) }},
+	{id: colPPID, title: "PPID", desc: "parent process ID", minWidth: 4, maxWidth: 8, style: pidStyle,
+		value: func(r columnRow) string {
+			if r.info.PID == 0 {
+				return ""
+			}
+			return strconv.Itoa(r.info.PPID)
+		}},
+	{id: colUptime, title: "UPTIME", desc: "time since the process started", minWidth: 6, maxWidth: 8, style: statStyle, sort: sortUptime,
+		value: func(r columnRow) string {
+			if r.info.StartTime.IsZero() {
+				return ""
+			}
+			return formatUptime(r.now.Sub(r.info.StartTime))
+		}},
+	{id: colCPU, title: "CPU", desc: "CPU usage", minWidth: 4, maxWidth: 6, style: statStyle,
+		value: func(r columnRow) string {
+			if r.info.PID == 0 {
+				return ""
+			}
+			return fmt.Sprintf("%.1f%%", r.info.CPU)
+		}},
+	{id: colMemory, title: "MEM", desc: "resident memory", minWidth: 4, maxWidth: 7, style: statStyle, sort: sortMemory,
+		value: func(r columnRow) string {
+			if r.info.PID == 0 {
+				return ""
+			}
+			return formatMemory(r.info.RSS)
+		}},
+	{id: colCwd, title: "CWD", desc: "working directory", minWidth: 8, style: commandStyle,
+		value: func(r columnRow) string { return shortenHome(r.extras.Cwd) }},
+	{id: colProject, title: "PROJECT", desc: "project of the working directory", minWidth: 4, maxWidth: 20, style: nameStyle,
+		value: func(r columnRow) string { return r.extras.Project }},
+	{id: colConns, title: "CONNS", desc: "established connections to its ports", minWidth: 3, maxWidth: 6, style: statStyle,
+		value: func(r columnRow) string {
+			if r.extras.Conns < 0 {
+				return ""
+			}
+			return strconv.Itoa(r.extras.Conns)
+		}},
+}
+
+// findColumn returns the column with the given ID
+func findColumn(id columnID) (column, bool) {
+	i := slices.IndexFunc(columnCatalog, func(c column) bool { return c.id == id })
+	if i < 0 {
+		return column{}, false
+	}
+	return columnCatalog[i], true
+}
+
+// columnNames returns the IDs of all columns, for help and error messages
+func columnNames() string {
+	names := make([]string, len(columnCatalog))
+	for i, c := range columnCatalog {
+		names[i] = string(c.id)
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseColumns parses a comma-separated list of column IDs, such as "port,pid,command"
+func parseColumns(s string) ([]columnID, error) {
+	var ids []columnID
+	for _, name := range strings.Split(s, ",") {
+		id := columnID(strings.ToLower(strings.TrimSpace(name)))
+		if _, ok := findColumn(id); !ok {
+			return nil, fmt.Errorf("unknown column %q (use %s)", name, columnNames())
+		}
+		if slices.Contains(ids, id) {
+			return nil, fmt.Errorf("column %q listed twice", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// processExtras are details of a process that are costly to gather, so they
+// are only gathered while a column shows them
+type processExtras struct {
+	Cwd     string
+	Project string
+	Conns   int  // established connections, -1 if not counted
+	cwdRead bool // whether reading Cwd was attempted, so a failure is not retried
+}
+
+// readProcessExtras gathers the details the shown columns need. Working
+// directories rarely change, so those already read are reused, as are
+// failures to read them, which need lsof on some systems.
+func readProcessExtras(processes []Process, columns []columnID, known map[int]processExtras) map[int]processExtras {
+	needCwd := slices.Contains(columns, colCwd) || slices.Contains(columns, colProject)
+	needConns := slices.Contains(columns, colConns)
+	if !needCwd && !needConns {
+		return nil
+	}
+
+	var conns map[int]int
+	if needConns {
+		// Leave the counts blank if lsof fails
+		conns, _ = countConnections(processes)
+	}
+	extras := make(map[int]processExtras, len(processes))
+	for _, p := range processes {
+		e := processExtras{Conns: -1}
+		if k, ok := known[p.PID]; ok && k.cwdRead {
+			e.Cwd, e.Project, e.cwdRead = k.Cwd, k.Project, true
+		} else if needCwd {
+			e.cwdRead = true
+			e.Cwd, _ = processCwd(p.PID)
+			if e.Cwd != "" {
+				e.Project = projectName(e.Cwd)
+			}
+		}
+		if conns != nil {
+			e.Conns = conns[p.PID]
+		}
+		extras[p.PID] = e
+	}
+	return extras
+}
+
+// columnValues returns the text of every column of a row
+func columnValues(cols []column, r columnRow) []string {
+	values := make([]string, len(cols))
+	for i, c := range cols {
+		values[i] = c.value(r)
+	}
+	return values
+}
+
+// naturalWidths returns the width each column needs for its title and the
+// widest of its values, up to its maximum width
+func naturalWidths(cols []column, titles []string, rows [][]string) []int {
+	widths := make([]int, len(cols))
+	for i, c := range cols {
+		widths[i] = ansi.StringWidth(titles[i])
+		for _, row := range rows {
+			widths[i] = max(widths[i], ansi.StringWidth(row[i]))
+		}
+		if c.maxWidth > 0 {
+			widths[i] = min(widths[i], max(c.maxWidth, ansi.StringWidth(titles[i])))
+		}
+	}
+	return widths
+}
+
+// layoutColumns returns the width of each column so that a row of columns
+// separated by spaces fits in width terminal cells, or uses the natural
+// widths if width is unknown (0). Columns that are too wide shrink down to
+// their minimum width, columns without a maximum first and then from the
+// right. Columns that still do not fit get width 0 and are left out.
+func layoutColumns(cols []column, natural []int, width int) []int {
+	widths := slices.Clone(natural)
+	if width <= 0 {
+		for i, c := range cols {
+			if c.maxWidth == 0 {
+				widths[i] = min(widths[i], DefaultCommandWidth)
+			}
+		}
+		return widths
+	}
+
+	over := len(cols) - 1 - width
+	for _, w := range widths {
+		over += w
+	}
+	for _, flexible := range []bool{true, false} {
+		for i := len(cols) - 1; i >= 0 && over > 0; i-- {
+			if (cols[i].maxWidth == 0) != flexible {
+				continue
+			}
+			cut := min(over, widths[i]-min(cols[i].minWidth, natural[i]))
+			if cut > 0 {
+				widths[i] -= cut
+				over -= cut
+			}
+		}
+	}
+	for i := len(cols) - 1; i > 0 && over > 0; i-- {
+		over -= widths[i] + 1
+		widths[i] = 0
+	}
+	return widths
+}
+
+// renderCell pads or truncates s to width terminal cells and renders it in
+// style, highlighting the fuzzy matches at the given byte offsets
+func renderCell(s string, width int, positions []int, style lipgloss.Style) string {
+	cell := truncate(s, width)
+	if len(positions) > 0 && ansi.StringWidth(s) > width {
+		// Matches in the part cut off are not shown
+		cut := len(ansi.Truncate(s, width-1, ""))
+		positions = slices.DeleteFunc(slices.Clone(positions), func(i int) bool { return i >= cut })
+	}
+	return highlightMatches(cell, positions, style)
+}
+
+// listenerAddresses returns the distinct bind addresses of a process
+func listenerAddresses(listeners []Listener) string {
+	var addrs []string
+	for _, l := range listeners {
+		if !slices.Contains(addrs, l.Address) {
+			addrs = append(addrs, l.Address)
+		}
+	}
+	return strings.Join(addrs, ", ")
+}
+
+// listenerProtocols returns the distinct protocols of a process, such as "tcp4,tcp6"
+func listenerProtocols(listeners []Listener) string {
+	var protocols []string
+	for _, l := range listeners {
+		if !slices.Contains(protocols, l.Protocol) {
+			protocols = append(protocols, l.Protocol)
+		}
+	}
+	slices.Sort(protocols)
+	return strings.Join(protocols, ",")
+}
+
+// formatUptime formats how long a process has been running, such as "45s",
+// "12m", "3h05m" or "2d4h"
+func formatUptime(d time.Duration) string {
+	d = max(d, 0)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+}
+
+// formatMemory formats a memory size given in KiB, such as "512K" or "12.3M"
+func formatMemory(kib int64) string {
+	switch {
+	case kib < 1024:
+		return fmt.Sprintf("%dK", kib)
+	case kib < 1024*1024:
+		return fmt.Sprintf("%.1fM", float64(kib)/1024)
+	}
+	return fmt.Sprintf("%.1fG", float64(kib)/(1024*1024))
+}
+
+// shortenHome replaces the home directory at the start of path with ~
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rest
+	}
+	return path
+}
+
+// columnChoice is a column in the column picker
+type columnChoice struct {
+	ID    columnID
+	Shown bool
+}
+
+// columnChoices returns every column for the column picker: the shown ones
+// in their order, then the hidden ones
+func columnChoices(shown []columnID) []columnChoice {
+	choices := make([]columnChoice, 0, len(columnCatalog))
+	for _, id := range shown {
+		choices = append(choices, columnChoice{ID: id, Shown: true})
+	}
+	for _, c := range columnCatalog {
+		if !slices.Contains(shown, c.id) {
+			choices = append(choices, columnChoice{ID: c.id})
+		}
+	}
+	return choices
+}
+
+// shownColumns returns the IDs of the shown columns of the picker, in order
+func shownColumns(choices []columnChoice) []columnID {
+	var ids []columnID
+	for _, c := range choices {
+		if c.Shown {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids
+}
+
+// columnsPath returns where the TUI remembers its columns between sessions
+func columnsPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "columns.json"), nil
+}
+
+// loadColumns returns the saved columns, or nil if none were saved
+func loadColumns() []columnID {
+	path, err := columnsPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var ids []columnID
+	if json.Unmarshal(data, &ids) != nil || len(ids) == 0 {
+		return nil
+	}
+	for _, id := range ids {
+		if _, ok := findColumn(id); !ok {
+			return nil
+		}
+	}
+	return ids
+}
+
+// saveColumns remembers the shown columns for the next session
+func saveColumns(ids []columnID) error {
+	path, err := columnsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
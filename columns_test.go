@@ -0,0 +1,198 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseColumns(t *testing.T) {
+	got, err := parseColumns("port, PID,uptime,cwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []columnID{colPort, colPID, colUptime, colCwd}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseColumns() = %v, expected %v", got, want)
+	}
+
+	for _, s := range []string{"port,size", "port,port", ""} {
+		if _, err := parseColumns(s); err == nil {
+			t.Errorf("parseColumns(%q) expected an error", s)
+		}
+	}
+}
+
+func TestLayoutColumns(t *testing.T) {
+	port, _ := findColumn(colPort)
+	pid, _ := findColumn(colPID)
+	command, _ := findColumn(colCommand)
+	user, _ := findColumn(colUser)
+	cols := []column{port, pid, user, command}
+	natural := []int{10, 5, 8, 40}
+
+	tests := []struct {
+		width int
+		want  []int
+	}{
+		{0, []int{10, 5, 8, 40}},   // unknown width
+		{100, []int{10, 5, 8, 40}}, // fits
+		{50, []int{10, 5, 8, 24}},  // the command shrinks first
+		{30, []int{10, 3, 4, 10}},  // then the others, from the right
+		{25, []int{5, 3, 4, 10}},
+		{20, []int{5, 3, 4, 0}}, // the command no longer fits
+	}
+	for _, tt := range tests {
+		if got := layoutColumns(cols, natural, tt.width); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("layoutColumns(width %d) = %v, expected %v", tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestNaturalWidths(t *testing.T) {
+	process, _ := findColumn(colProcess)
+	command, _ := findColumn(colCommand)
+	cols := []column{process, command}
+	rows := [][]string{
+		{"node", "vite (web)"},
+		{"a-very-long-process-name", "サーバー"},
+	}
+	got := naturalWidths(cols, []string{"PROCESS ▲", "COMMAND"}, rows)
+	// The process name is capped at its maximum; wide characters count double
+	if want := []int{15, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("naturalWidths() = %v, expected %v", got, want)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"node", 6, "node  "},
+		{"postgres", 6, "postg…"},
+		{"サーバー", 6, "サー… "}, // two cells per character
+		{"café", 4, "café"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.width); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, expected %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestRenderCellDropsMatchesCutOff(t *testing.T) {
+	full := renderCell("workerd", 4, []int{0, 5}, commandStyle)
+	cut := renderCell("workerd", 4, []int{0}, commandStyle)
+	if full != cut {
+		t.Errorf("expected matches in the truncated part to be dropped, got %q and %q", full, cut)
+	}
+}
+
+func TestColumnValues(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	r := columnRow{
+		Process: Process{PID: 42, Ports: []int{3000, 3001}, Listeners: []Listener{
+			{Address: "127.0.0.1", Port: 3000, Protocol: "tcp4"},
+			{Address: "::1", Port: 3000, Protocol: "tcp6"},
+			{Address: "127.0.0.1", Port: 3001, Protocol: "tcp4"},
+		}},
+		info:   procInfo{PID: 42, PPID: 1, RSS: 52344, CPU: 3.25, StartTime: now.Add(-(3*time.Hour + 5*time.Minute))},
+		extras: processExtras{Cwd: "/srv/api", Project: "api", Conns: 7},
+		now:    now,
+	}
+
+	var cols []column
+	for _, id := range []columnID{colPort, colAddress, colProto, colPPID, colUptime, colCPU, colMemory, colCwd, colProject, colConns} {
+		c, _ := findColumn(id)
+		cols = append(cols, c)
+	}
+	got := columnValues(cols, r)
+	want := []string{"3000, 3001", "127.0.0.1, ::1", "tcp4,tcp6", "1", "3h05m", "3.2%", "51.1M", "/srv/api", "api", "7"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("columnValues() = %q, expected %q", got, want)
+	}
+
+	// Details that were not gathered are left blank
+	got = columnValues(cols, columnRow{Process: Process{PID: 42}, extras: processExtras{Conns: -1}, now: now})
+	if strings.Join(got, "") != "" {
+		t.Errorf("expected blank values without details, got %q", got)
+	}
+}
+
+func TestFormatUptime(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{45 * time.Second, "45s"},
+		{12 * time.Minute, "12m"},
+		{3*time.Hour + 5*time.Minute, "3h05m"},
+		{52 * time.Hour, "2d4h"},
+	}
+	for _, tt := range tests {
+		if got := formatUptime(tt.d); got != tt.want {
+			t.Errorf("formatUptime(%s) = %q, expected %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestProjectName(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "web-app")
+	dir := filepath.Join(root, "packages", "server")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := projectName(dir); got != "" {
+		t.Errorf("expected no project without markers, got %q", got)
+	}
+	if err := os.WriteFile(filepath.Join(root, "package.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := projectName(dir); got != "web-app" {
+		t.Errorf("projectName() = %q, expected web-app", got)
+	}
+}
+
+func TestSaveColumns(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	if got := loadColumns(); got != nil {
+		t.Errorf("expected no columns before saving, got %v", got)
+	}
+	want := []columnID{colPort, colMemory, colCommand}
+	if err := saveColumns(want); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadColumns(); !reflect.DeepEqual(got, want) {
+		t.Errorf("loadColumns() = %v, expected %v", got, want)
+	}
+}
+
+func TestReadProcessExtrasReusesCwd(t *testing.T) {
+	wd, _ := os.Getwd()
+	pid := os.Getpid()
+	processes := []Process{{PID: pid}}
+
+	tests := []struct {
+		name  string
+		known map[int]processExtras
+		want  string
+	}{
+		{"unknown", nil, wd},
+		{"read before", map[int]processExtras{pid: {Cwd: "/srv/api", cwdRead: true}}, "/srv/api"},
+		{"failed before", map[int]processExtras{pid: {cwdRead: true}}, ""},
+		{"only connections counted before", map[int]processExtras{pid: {Conns: 3}}, wd},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extras := readProcessExtras(processes, []columnID{colCwd}, tt.known)
+			if got := extras[pid].Cwd; got != tt.want {
+				t.Errorf("cwd = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// completionShells are the shells completion scripts can be generated for
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completion is a single completion candidate
+type completion struct {
+	value       string
+	description string
+}
+
+// flagValues lists the values offered for flags that take one of a fixed set
+var flagValues = map[string][]completion{
+	"kill-policy": {
+		{"ask", "prompt before killing"},
+		{"always", "kill without asking"},
+		{"never", "fail instead of killing"},
+	},
+}
+
+// signalCompletions returns the names of all signals portsweep knows, sorted
+func signalCompletions() []completion {
+	candidates := make([]completion, 0, len(signalNames))
+	for _, name := range signalNames {
+		candidates = append(candidates, completion{value: name})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].value < candidates[j].value
+	})
+	return candidates
+}
+
+// runCompletion implements `portsweep completion <shell>`
+func runCompletion(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: portsweep completion bash|zsh|fish")
+		return 2
+	}
+
+	var script string
+	switch args[0] {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		eprintf("unsupported shell %q (expected bash, zsh or fish)", args[0])
+		return 2
+	}
+	os.Stdout.WriteString(script)
+	return 0
+}
+
+// runComplete implements the hidden `portsweep __complete <words...>` command
+// used by the completion scripts. The words are everything after "portsweep"
+// up to and including the word being completed. Candidates are printed one
+// per line as "value<TAB>description".
+func runComplete(args []string) int {
+	for _, c := range completeWords(args, defaultScanner) {
+		if c.description != "" {
+			fmt.Printf("%s\t%s\n", c.value, c.description)
+		} else {
+			fmt.Println(c.value)
+		}
+	}
+	return 0
+}
+
+// completeWords returns the candidates for the last word given the words before it
+func completeWords(words []string, scanner PortScanner) []completion {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	previous := words[:len(words)-1]
+
+	var candidates []completion
+	if len(previous) == 0 {
+		// First word: a subcommand, a root flag or a filter
+		if strings.HasPrefix(current, "-") {
+			candidates = flagCompletions(newRootFlags(&rootOptions{}))
+		} else {
+			candidates = append(commandCompletions(), processCompletions(scanner)...)
+		}
+		return filterCompletions(candidates, current)
+	}
+
+	cmd := findCommand(previous[0])
+	if cmd == nil {
+		// Interactive mode: flags and the filter can be mixed
+		fs := newRootFlags(&rootOptions{})
+		if values, ok := flagValueCompletions(fs, previous[len(previous)-1]); ok {
+			return filterCompletions(values, current)
+		}
+		if strings.HasPrefix(current, "-") {
+			return filterCompletions(flagCompletions(fs), current)
+		}
+		return filterCompletions(processCompletions(scanner), current)
+	}
+
+	// Nothing to offer once the wrapped command starts
+	for _, word := range previous[1:] {
+		if word == "--" {
+			return nil
+		}
+	}
+
+	if cmd.flags != nil {
+		fs := cmd.flags()
+		if values, ok := flagValueCompletions(fs, previous[len(previous)-1]); ok {
+			return filterCompletions(values, current)
+		}
+		if strings.HasPrefix(current, "-") {
+			return filterCompletions(flagCompletions(fs), current)
+		}
+	}
+
+	if len(previous) == 1 {
+		for _, arg := range cmd.args {
+			candidates = append(candidates, completion{value: arg})
+		}
+	} else if cmd.name == "snapshot" && len(previous) == 3 && previous[1] == "diff" {
+		candidates = append(candidates, completion{liveSnapshot, "compare with the current listeners"})
+	}
+	return filterCompletions(candidates, current)
+}
+
+// commandCompletions returns the visible subcommands
+func commandCompletions() []completion {
+	var candidates []completion
+	for _, cmd := range subcommands() {
+		if !cmd.hidden {
+			candidates = append(candidates, completion{cmd.name, cmd.summary})
+		}
+	}
+	return candidates
+}
+
+// flagCompletions returns every flag of a flag set in --name form
+func flagCompletions(fs *flag.FlagSet) []completion {
+	var candidates []completion
+	fs.VisitAll(func(f *flag.Flag) {
+		candidates = append(candidates, completion{"--" + f.Name, f.Usage})
+	})
+	return candidates
+}
+
+// flagValueCompletions returns the candidates for the value of the flag named by
+// word, and whether word is a flag that takes a value at all.
+func flagValueCompletions(fs *flag.FlagSet, word string) ([]completion, bool) {
+	if !strings.HasPrefix(word, "-") || strings.Contains(word, "=") {
+		return nil, false
+	}
+	name := strings.TrimLeft(word, "-")
+	f := fs.Lookup(name)
+	if f == nil {
+		return nil, false
+	}
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return nil, false
+	}
+	if name == "signal" {
+		return signalCompletions(), true
+	}
+	return flagValues[name], true
+}
+
+// processCompletions returns the ports and process names currently listening,
+// each described by the formatted command of its process.
+func processCompletions(scanner PortScanner) []completion {
+	processes, err := scanner.GetListeningPorts()
+	if err != nil {
+		return nil
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].LowestPort() < processes[j].LowestPort()
+	})
+
+	var ports, names []completion
+	seenNames := make(map[string]bool)
+	for _, p := range processes {
+		label := processLabel(p)
+		for _, port := range p.Ports {
+			ports = append(ports, completion{strconv.Itoa(port), label})
+		}
+		if !seenNames[p.Name] {
+			seenNames[p.Name] = true
+			names = append(names, completion{p.Name, label})
+		}
+	}
+	return append(ports, names...)
+}
+
+// filterCompletions keeps the candidates that start with prefix
+func filterCompletions(candidates []completion, prefix string) []completion {
+	var result []completion
+	for _, c := range candidates {
+		if strings.HasPrefix(c.value, prefix) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// bashCompletion is the bash completion script. Descriptions are shown next to
+// the values when there is more than one candidate.
+const bashCompletion = `# bash completion for portsweep
+# Install with: source <(portsweep completion bash)
+
+_portsweep() {
+    local IFS=$'\n'
+    local lines=($(portsweep __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+
+    COMPREPLY=()
+    if [[ ${#lines[@]} -eq 1 ]]; then
+        COMPREPLY=("${lines[0]%%$'\t'*}")
+        return
+    fi
+
+    local line value desc
+    for line in "${lines[@]}"; do
+        value="${line%%$'\t'*}"
+        if [[ "$line" == *$'\t'* ]]; then
+            desc="${line#*$'\t'}"
+            COMPREPLY+=("$(printf '%-12s -- %s' "$value" "$desc")")
+        else
+            COMPREPLY+=("$value")
+        fi
+    done
+}
+
+complete -o default -F _portsweep portsweep
+`
+
+// zshCompletion is the zsh completion script
+const zshCompletion = `#compdef portsweep
+# zsh completion for portsweep
+# Install with: source <(portsweep completion zsh)
+# or save it as _portsweep in a directory on your $fpath
+
+_portsweep() {
+    local -a candidates
+    local line value desc
+    for line in "${(@f)$(portsweep __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}"; do
+        [[ -z "$line" ]] && continue
+        value="${line%%$'\t'*}"
+        value="${value//:/\\:}"
+        if [[ "$line" == *$'\t'* ]]; then
+            desc="${line#*$'\t'}"
+            candidates+=("$value:$desc")
+        else
+            candidates+=("$value")
+        fi
+    done
+
+    if (( ${#candidates} )); then
+        _describe 'portsweep' candidates
+    else
+        _files
+    fi
+}
+
+if [[ "$funcstack[1]" = "_portsweep" ]]; then
+    _portsweep "$@"
+else
+    compdef _portsweep portsweep
+fi
+`
+
+// fishCompletion is the fish completion script
+const fishCompletion = `# fish completion for portsweep
+# Install with: portsweep completion fish > ~/.config/fish/completions/portsweep.fish
+
+function __portsweep_complete
+    set -l words (commandline -opc)[2..-1] (commandline -ct)
+    portsweep __complete $words 2>/dev/null
+end
+
+complete -c portsweep -f -a '(__portsweep_complete)'
+`
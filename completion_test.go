@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCompleteWords(t *testing.T) {
+	scanner := &MockScanner{
+		Processes: []Process{
+			{PID: 2, Ports: []int{5432}, Name: "postgres", Command: "/usr/bin/postgres"},
+			{PID: 1, Ports: []int{3000, 3001}, Name: "node", Command: "node /Users/me/Code/web-app/node_modules/.bin/vite"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		words    []string
+		expected []completion
+	}{
+		{
+			name:  "ports and names by prefix",
+			words: []string{"30"},
+			expected: []completion{
+				{"3000", "vite (web-app)"},
+				{"3001", "vite (web-app)"},
+			},
+		},
+		{
+			name:  "subcommands and names",
+			words: []string{"po"},
+			expected: []completion{
+				{"postgres", "postgres"},
+			},
+		},
+		{
+			name:     "subcommand names",
+			words:    []string{"wa"},
+			expected: []completion{{"watch", "Print an event whenever a listener opens, closes or changes owner"}},
+		},
+		{
+			name:     "root flags",
+			words:    []string{"--inl"},
+			expected: []completion{{"--inline", "render in the normal screen instead of the alternate screen"}},
+		},
+		{
+			name:     "filter after root flag",
+			words:    []string{"--inline", "54"},
+			expected: []completion{{"5432", "postgres"}},
+		},
+		{
+			name:     "subcommand flags",
+			words:    []string{"exec", "--kill"},
+			expected: []completion{{"--kill-policy", "whether to kill holders of the port: ask, always or never"}},
+		},
+		{
+			name:     "flag values",
+			words:    []string{"exec", "--kill-policy", "n"},
+			expected: []completion{{"never", "fail instead of killing"}},
+		},
+		{
+			name:     "no completion inside the wrapped command",
+			words:    []string{"exec", "--port", "3000", "--", "np"},
+			expected: nil,
+		},
+		{
+			name:     "fixed arguments",
+			words:    []string{"completion", "z"},
+			expected: []completion{{"zsh", ""}},
+		},
+		{
+			name:     "snapshot diff live",
+			words:    []string{"snapshot", "diff", "a.json", ""},
+			expected: []completion{{"live", "compare with the current listeners"}},
+		},
+		{
+			name:     "hidden commands are not offered",
+			words:    []string{"__"},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := completeWords(tt.words, scanner)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("completeWords(%q) = %v, expected %v", tt.words, got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("completeWords(%q)[%d] = %v, expected %v", tt.words, i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
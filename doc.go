@@ -5,7 +5,26 @@
 //   - View all processes listening on TCP ports
 //   - Select and kill multiple processes at once
 //   - Filter by port number or process name
-//   - Search processes interactively
+//   - Search processes interactively with a field-qualified query language
+//   - Fuzzy search with ranked results and highlighted matches
+//   - Scroll through long process lists that do not fit the terminal
+//   - See which listeners just appeared or exited since the last refresh
+//   - Sort by any column, including uptime and memory, remembered between sessions
+//   - Choose and reorder columns, from bind address to connection count
+//   - Inspect the focused process in a detail pane, with secrets in its environment masked
+//   - List one row per listening socket instead of one per process
+//   - Clear a port before running a command (portsweep exec)
+//   - Run a command on a free port (portsweep run)
+//   - Resolve "address already in use" errors of a dev command (portsweep guard)
+//   - Detect listeners leaked by test suites (portsweep leak-check)
+//   - Save and compare snapshots of listeners (portsweep snapshot)
+//   - Stream listener open/close events (portsweep watch)
+//   - Review kills before running them (portsweep plan / apply)
+//   - Rehearse kills without sending any signal (--dry-run)
+//   - Undo confirmed kills during a short countdown (--kill-delay)
+//   - Keep an audit log of every kill (portsweep history)
+//   - Restart a process with its original command, directory and environment (portsweep restart)
+//   - Shut down each kind of server the way it expects (shutdown strategies)
 //
 // The application uses the Bubbletea framework with the Elm architecture pattern
 // for state management.
@@ -21,6 +40,29 @@
 //   - keys.go: Key bindings configuration
 //   - messages.go: TUI message types for the Elm architecture
 //   - helpers.go: Utility functions for string formatting
+//   - cli.go: Shared helpers for subcommands (prompts, kill policies, port clearing)
+//   - subprocess.go: Running user commands with signal forwarding
+//   - proctable.go: Process table lookups (parent PIDs, memory, CPU, start times and projects)
+//   - exec.go: The exec subcommand
+//   - run.go: The run subcommand
+//   - guard.go: The guard subcommand and EADDRINUSE detection
+//   - leakcheck.go: The leak-check subcommand
+//   - snapshot.go: The snapshot subcommand and snapshot diffing
+//   - watch.go: The watch subcommand
+//   - plan.go: The plan and apply subcommands
+//   - history.go: The kill audit log and the history subcommand
+//   - restart.go: Capturing how a process was started and relaunching it
+//   - strategy.go: Per-application shutdown strategies (StrategyKiller)
+//   - query.go: The query language shared by search and CLI filters
+//   - searchhistory.go: Persistent history of searches
+//   - fuzzy.go: Fuzzy matching, ranking and match highlighting
+//   - sort.go: Sorting the process list and remembering the sort order
+//   - columns.go: The columns of the process list, their layout and the column picker's choices
+//   - detail.go: The detail pane of the focused process and secret masking
+//   - listenerview.go: Rows of the one-row-per-listener view
+//   - plain.go: Plain-text output when not attached to a terminal
+//   - summary.go: The summary of signalled processes printed on exit
+//   - completion.go: Shell completion scripts and live completion candidates
 //
 // # Extensibility
 //
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// execOptions holds the flags for the exec subcommand
+type execOptions struct {
+	port       int
+	killPolicy killPolicy
+	timeout    time.Duration
+	dryRun     bool
+}
+
+// newExecFlags returns the flag set for the exec subcommand bound to opts
+func newExecFlags(opts *execOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("exec", flag.ContinueOnError)
+	fs.IntVar(&opts.port, "port", 0, "port to clear before running the command")
+	fs.Var(&opts.killPolicy, "kill-policy", "whether to kill holders of the port: ask, always or never")
+	fs.DurationVar(&opts.timeout, "timeout", DefaultReleaseTimeout, "how long to wait for the port to be released")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "report what would be killed and run without sending signals or running the command")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: portsweep exec --port <port> [--kill-policy ask|always|never] [--dry-run] -- <command> [args...]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Kill whatever is listening on the port, wait until it is released, then run the command.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runExec implements `portsweep exec`: it clears a port and then runs a
+// command, passing signals through and exiting with the command's status.
+func runExec(args []string) int {
+	opts := execOptions{killPolicy: killPolicyAsk}
+	fs := newExecFlags(&opts)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	argv := fs.Args()
+	if opts.port <= 0 || len(argv) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if opts.dryRun {
+		killer := newDryRunKiller("exec")
+		holders, err := killHolders(defaultScanner, killer, opts.port, opts.killPolicy, promptConfirm)
+		if errors.Is(err, errAborted) {
+			return 1
+		}
+		if err != nil {
+			eprintf("%v", err)
+			return 1
+		}
+		for _, p := range holders {
+			eprintf("would kill %s (pid %d) with %s (dry run)", processLabel(p), p.PID, killDescription(killer, p))
+		}
+		eprintf("would run %s (dry run)", strings.Join(argv, " "))
+		return 0
+	}
+
+	err := clearPort(defaultScanner, newKiller("exec"), opts.port, opts.killPolicy, opts.timeout, promptConfirm)
+	if errors.Is(err, errAborted) {
+		return 1
+	}
+	if err != nil {
+		eprintf("%v", err)
+		return 1
+	}
+
+	return runChild(argv, nil)
+}
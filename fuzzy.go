@@ -0,0 +1,214 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Fuzzy match scoring. Every matched character scores fuzzyScoreMatch, runs
+// of consecutive characters and characters at the start of a word score
+// extra, and characters skipped between two matches cost a little.
+const (
+	fuzzyScoreMatch       = 16
+	fuzzyBonusConsecutive = 8
+	fuzzyBonusBoundary    = 10
+	fuzzyPenaltyGap       = 1
+)
+
+// fuzzyField is a part of a process that fuzzy search matches against
+type fuzzyField int
+
+const (
+	fuzzyPort       fuzzyField = iota // ports as displayed in the list
+	fuzzyName                         // process name
+	fuzzyCommand                      // formatted command
+	fuzzyRawCommand                   // full command line
+)
+
+// fuzzyResult is how well a process matches a fuzzy pattern
+type fuzzyResult struct {
+	Score     int
+	Positions map[fuzzyField][]int // byte offsets of the matched characters in each field
+}
+
+// fuzzyFieldTexts returns the text of each field fuzzy search looks at
+func fuzzyFieldTexts(p Process) map[fuzzyField]string {
+	return map[fuzzyField]string{
+		fuzzyPort:       formatPorts(p.Ports, 18),
+		fuzzyName:       p.Name,
+		fuzzyCommand:    formatCommand(p.Command),
+		fuzzyRawCommand: p.Command,
+	}
+}
+
+// fuzzyMatchProcess matches a pattern against a process. Each word of the
+// pattern has to match one of the fields, so "workerd api" finds the workerd
+// process of the api project. The score is the sum of the best field score
+// of every word.
+func fuzzyMatchProcess(pattern string, p Process) (fuzzyResult, bool) {
+	texts := fuzzyFieldTexts(p)
+	result := fuzzyResult{Positions: make(map[fuzzyField][]int)}
+	for _, word := range strings.Fields(pattern) {
+		bestField, bestScore, found := fuzzyField(0), 0, false
+		var bestPositions []int
+		for _, field := range []fuzzyField{fuzzyPort, fuzzyName, fuzzyCommand, fuzzyRawCommand} {
+			score, positions, ok := fuzzyMatch(word, texts[field])
+			if ok && (!found || score > bestScore) {
+				bestField, bestScore, bestPositions, found = field, score, positions, true
+			}
+		}
+		if !found {
+			return fuzzyResult{}, false
+		}
+		result.Score += bestScore
+		result.Positions[bestField] = append(result.Positions[bestField], bestPositions...)
+	}
+	return result, true
+}
+
+// rankFuzzy returns the processes matching a fuzzy pattern, best match first,
+// and how each of them matched. Equally good matches keep their order.
+func rankFuzzy(pattern string, processes []Process) ([]Process, []fuzzyResult) {
+	type ranked struct {
+		p Process
+		r fuzzyResult
+	}
+	var matches []ranked
+	for _, p := range processes {
+		if r, ok := fuzzyMatchProcess(pattern, p); ok {
+			matches = append(matches, ranked{p, r})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b ranked) int {
+		return cmp.Compare(b.r.Score, a.r.Score)
+	})
+	result := make([]Process, len(matches))
+	results := make([]fuzzyResult, len(matches))
+	for i, m := range matches {
+		result[i], results[i] = m.p, m.r
+	}
+	return result, results
+}
+
+// fuzzyMatch reports whether the characters of pattern appear in text in
+// order, ignoring case. It scores the best alignment and returns the byte
+// offsets of the characters it matched.
+func fuzzyMatch(pattern, text string) (int, []int, bool) {
+	pat := []rune(strings.ToLower(pattern))
+	var runes []rune
+	var offsets []int
+	for i, r := range text {
+		runes = append(runes, r)
+		offsets = append(offsets, i)
+	}
+	if len(pat) == 0 || len(pat) > len(runes) {
+		return 0, nil, len(pat) == 0
+	}
+
+	// score[i][j] is the best score of matching pat[:i+1] with pat[i] at
+	// runes[j], or -1 if there is no such match. from[i][j] is where pat[i-1]
+	// was matched in that alignment.
+	score := make([][]int, len(pat))
+	from := make([][]int, len(pat))
+	for i := range pat {
+		score[i] = make([]int, len(runes))
+		from[i] = make([]int, len(runes))
+		// best tracks the previous row's best score[i-1][k] + k*gap for k < j-1
+		best, bestAt := -1, -1
+		for j, r := range runes {
+			score[i][j] = -1
+			if i > 0 && j >= 2 && score[i-1][j-2] >= 0 {
+				if v := score[i-1][j-2] + (j-2)*fuzzyPenaltyGap; v > best {
+					best, bestAt = v, j-2
+				}
+			}
+			if unicode.ToLower(r) != pat[i] {
+				continue
+			}
+			base := fuzzyScoreMatch
+			if j == 0 || isWordBoundary(runes[j-1], r) {
+				base += fuzzyBonusBoundary
+			}
+			if i == 0 {
+				score[i][j] = base
+				continue
+			}
+			if j > 0 && score[i-1][j-1] >= 0 {
+				score[i][j] = score[i-1][j-1] + base + fuzzyBonusConsecutive
+				from[i][j] = j - 1
+			}
+			if best >= 0 {
+				// Skipping the characters between bestAt and j costs a penalty each
+				if v := best - (j-1)*fuzzyPenaltyGap + base; v > score[i][j] {
+					score[i][j] = v
+					from[i][j] = bestAt
+				}
+			}
+		}
+	}
+
+	last := len(pat) - 1
+	end := -1
+	for j := range runes {
+		if score[last][j] >= 0 && (end < 0 || score[last][j] > score[last][end]) {
+			end = j
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+	positions := make([]int, len(pat))
+	for i, j := last, end; i >= 0; i-- {
+		positions[i] = offsets[j]
+		j = from[i][j]
+	}
+	return score[last][end], positions, true
+}
+
+// isWordBoundary reports whether a character starts a new word, after a
+// separator or at a lower to upper case change
+func isWordBoundary(prev, r rune) bool {
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(r)
+}
+
+// highlightMatches renders s with style, and the characters at the given
+// byte offsets with fuzzyMatchStyle on top of it
+func highlightMatches(s string, positions []int, style lipgloss.Style) string {
+	if len(positions) == 0 {
+		return style.Render(s)
+	}
+	// s is already padded, and a width would pad every segment
+	style = style.UnsetWidth()
+	matchStyle := fuzzyMatchStyle.Inherit(style)
+	var sb strings.Builder
+	var run strings.Builder
+	runMatched := false
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		if runMatched {
+			sb.WriteString(matchStyle.Render(run.String()))
+		} else {
+			sb.WriteString(style.Render(run.String()))
+		}
+		run.Reset()
+	}
+	for i, r := range s {
+		matched := slices.Contains(positions, i)
+		if matched != runMatched {
+			flush()
+			runMatched = matched
+		}
+		run.WriteRune(r)
+	}
+	flush()
+	return sb.String()
+}
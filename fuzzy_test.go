@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		ok            bool
+		positions     []int
+	}{
+		{"wrkd", "workerd", true, []int{0, 2, 3, 6}},
+		{"WRKD", "workerd", true, []int{0, 2, 3, 6}},
+		{"dkrw", "workerd", false, nil},
+		{"api", "rapid api", true, []int{6, 7, 8}}, // prefers the start of a word
+		{"sj", "node server.js", true, []int{5, 12}},
+		{"é", "café", true, []int{3}}, // byte offsets
+		{"toolong", "tool", false, nil},
+		{"", "anything", true, nil},
+	}
+
+	for _, tt := range tests {
+		_, positions, ok := fuzzyMatch(tt.pattern, tt.text)
+		if ok != tt.ok || !reflect.DeepEqual(positions, tt.positions) {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, %v; expected %v, %v", tt.pattern, tt.text, positions, ok, tt.positions, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyMatchPrefersConsecutive(t *testing.T) {
+	consecutive, _, _ := fuzzyMatch("vite", "node vite")
+	scattered, _, _ := fuzzyMatch("vite", "very important test")
+	if consecutive <= scattered {
+		t.Errorf("expected consecutive match to score higher, got %d <= %d", consecutive, scattered)
+	}
+}
+
+func TestRankFuzzy(t *testing.T) {
+	processes := []Process{
+		{PID: 1, Ports: []int{8787}, Name: "workerd", Command: "/Users/me/Code/web/node_modules/.bin/workerd serve"},
+		{PID: 2, Ports: []int{8788}, Name: "workerd", Command: "/Users/me/Code/api/node_modules/.bin/workerd serve"},
+		{PID: 3, Ports: []int{3000}, Name: "node", Command: "node server.js"},
+	}
+
+	got, _ := rankFuzzy("workerd api", processes)
+	if len(got) != 1 || got[0].PID != 2 {
+		t.Errorf("expected only the workerd of the api project, got %v", got)
+	}
+
+	got, _ = rankFuzzy("8788", processes)
+	if len(got) != 1 || got[0].PID != 2 {
+		t.Errorf("expected port 8788 to match, got %v", got)
+	}
+
+	// Scattered matches rank below consecutive ones, whatever the list order
+	processes = append([]Process{{PID: 4, Ports: []int{5173}, Name: "node", Command: "node watch-or-kill.js"}}, processes...)
+	got, _ = rankFuzzy("work", processes)
+	if len(got) != 3 || got[0].PID != 1 || got[1].PID != 2 || got[2].PID != 4 {
+		t.Errorf("expected both workerd processes ranked first, got %v", got)
+	}
+}
+
+func TestFuzzyMatchProcessPositions(t *testing.T) {
+	p := Process{Ports: []int{3000}, Name: "vite", Command: "node vite"}
+	r, ok := fuzzyMatchProcess("vt 300", p)
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	if !reflect.DeepEqual(r.Positions[fuzzyName], []int{0, 2}) {
+		t.Errorf("expected vt highlighted in the name, got %v", r.Positions)
+	}
+	if !reflect.DeepEqual(r.Positions[fuzzyPort], []int{0, 1, 2}) {
+		t.Errorf("expected 300 highlighted in the port, got %v", r.Positions)
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Configuration for the guard subcommand
+const (
+	// DefaultMaxRestarts is how many times guard relaunches a command after a port conflict
+	DefaultMaxRestarts = 3
+
+	// portLookahead is how many lines after an "address in use" error may still name the port
+	portLookahead = 5
+
+	// maxLineLength bounds how much of a single unterminated stderr line is buffered for matching
+	maxLineLength = 64 * 1024
+
+	// ConflictSettleTime is how long guard lets a command that reported a port
+	// conflict print the rest of the error, which may name the port, or exit
+	ConflictSettleTime = 500 * time.Millisecond
+)
+
+// Pre-compiled patterns for recognising port conflicts in stderr output
+var (
+	// addrInUseRegex matches the "address already in use" errors printed by
+	// Node (EADDRINUSE), Go, Python, Rust (AddrInUse) and Java (BindException),
+	// plus the friendlier messages from Spring Boot and Flask.
+	addrInUseRegex = regexp.MustCompile(`(?i)EADDRINUSE|address already in use|AddrInUse|port \d+ (?:was|is) already in use|port \d+ is in use`)
+
+	// Patterns that extract the port, in order of preference
+	portWordRegex    = regexp.MustCompile(`(?i)\bport (\d{1,5})\b`)            // Port 8080 was already in use
+	pythonAddrRegex  = regexp.MustCompile(`\(\s*'[^']*'\s*,\s*(\d{1,5})\s*\)`) // ('127.0.0.1', 8000)
+	hostPortRegex    = regexp.MustCompile(`(?:[\w.*:\]-]|^|\s):(\d{1,5})\b`)   // :::3000, 127.0.0.1:3000, tcp :8080:
+	portExtractRegex = []*regexp.Regexp{portWordRegex, pythonAddrRegex, hostPortRegex}
+)
+
+// guardOptions holds the flags for the guard subcommand
+type guardOptions struct {
+	port        int
+	killPolicy  killPolicy
+	maxRestarts int
+	dryRun      bool
+}
+
+// newGuardFlags returns the flag set for the guard subcommand bound to opts
+func newGuardFlags(opts *guardOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("guard", flag.ContinueOnError)
+	fs.IntVar(&opts.port, "port", 0, "port to assume when the error message does not name one")
+	fs.Var(&opts.killPolicy, "kill-policy", "whether to kill the conflicting holder: ask, always or never")
+	fs.IntVar(&opts.maxRestarts, "max-restarts", DefaultMaxRestarts, "maximum number of restarts after port conflicts")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "on a conflict, report what would be killed without sending signals or restarting")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: portsweep guard [--port <port>] [--kill-policy ask|always|never] [--dry-run] -- <command> [args...]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Run the command and watch its stderr for \"address already in use\" errors. When it reports")
+		fmt.Fprintln(fs.Output(), "one, whether it exits or keeps running, offer to kill the process holding the port and")
+		fmt.Fprintln(fs.Output(), "restart the command.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runGuard implements `portsweep guard`
+func runGuard(args []string) int {
+	opts := guardOptions{killPolicy: killPolicyAsk}
+	fs := newGuardFlags(&opts)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	argv := fs.Args()
+	if len(argv) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	for restarts := 0; ; restarts++ {
+		watcher := newAddrInUseWatcher(os.Stderr)
+		c, err := startChildGroup(argv, nil, watcher)
+		if err != nil {
+			eprintf("%v", err)
+			return 127
+		}
+		run := watchChild(c, watcher)
+
+		conflict, port := watcher.Conflict()
+		if !conflict {
+			return run.wait()
+		}
+		if port == 0 {
+			port = opts.port
+		}
+		if port == 0 {
+			eprintf("address already in use, but the port could not be determined (pass --port)")
+			return run.wait()
+		}
+		if restarts >= opts.maxRestarts {
+			eprintf("port %d still in use, giving up after %d restarts", port, restarts)
+			return run.wait()
+		}
+
+		if opts.dryRun {
+			rehearseConflict(port, opts.killPolicy)
+			return run.wait()
+		}
+		if !resolveConflict(port, opts.killPolicy) {
+			return run.wait()
+		}
+		// A command that kept running after the error is restarted too
+		run.stop()
+		eprintf("restarting %s", argv[0])
+	}
+}
+
+// guardedRun is a command run by guard, which may still be running after it
+// reported a port conflict
+type guardedRun struct {
+	child  *child
+	exited chan int // receives the exit code once
+	code   int
+	done   bool
+}
+
+// watchChild waits until the child exits or reports a port conflict. A
+// conflict is checked for while the child runs, since servers that retry or
+// only log the error never exit on their own.
+func watchChild(c *child, watcher *addrInUseWatcher) *guardedRun {
+	run := &guardedRun{child: c, exited: make(chan int, 1)}
+	go func() { run.exited <- c.Wait() }()
+
+	select {
+	case run.code = <-run.exited:
+		run.done = true
+	case <-watcher.Detected():
+		select {
+		case run.code = <-run.exited:
+			run.done = true
+		case <-time.After(ConflictSettleTime):
+		}
+	}
+	if run.done {
+		watcher.Flush()
+	} else {
+		// Guard reports the conflict, and may prompt, while the command runs
+		c.takeTerminal()
+	}
+	return run
+}
+
+// wait waits for the command to exit and returns its exit code
+func (r *guardedRun) wait() int {
+	if !r.done {
+		r.child.giveTerminal()
+		r.code = <-r.exited
+		r.done = true
+	}
+	return r.code
+}
+
+// stop terminates the command and the processes it started if it is still
+// running, escalating to SIGKILL if it does not exit in time
+func (r *guardedRun) stop() {
+	if r.done {
+		return
+	}
+	_ = r.child.signal(syscall.SIGTERM)
+	select {
+	case r.code = <-r.exited:
+	case <-time.After(DefaultReleaseTimeout):
+		_ = r.child.signal(syscall.SIGKILL)
+		r.code = <-r.exited
+	}
+	r.done = true
+}
+
+// resolveConflict frees the port according to the kill policy and reports
+// whether the command should be restarted.
+func resolveConflict(port int, policy killPolicy) bool {
+	holders, err := holdersOf(defaultScanner, port)
+	if err != nil {
+		eprintf("scanning ports: %v", err)
+		return false
+	}
+	if len(holders) == 0 {
+		eprintf("port %d is free now", port)
+		return true
+	}
+
+	label := processLabel(holders[0])
+	if len(holders) > 1 {
+		label += fmt.Sprintf(" and %d more", len(holders)-1)
+	}
+
+	killer := newKiller("guard")
+	switch policy {
+	case killPolicyNever:
+		eprintf("port %d held by %s (pid %d)", port, label, holders[0].PID)
+		return false
+	case killPolicyAsk:
+		question := fmt.Sprintf("port %d held by %s, kill (%s) and restart?", port, label, killDescription(killer, holders[0]))
+		if !promptConfirm(question) {
+			return false
+		}
+	}
+
+	if err := clearPort(defaultScanner, killer, port, killPolicyAlways, DefaultReleaseTimeout, nil); err != nil {
+		eprintf("%v", err)
+		return false
+	}
+	return true
+}
+
+// rehearseConflict reports what resolveConflict would kill, without killing
+// anything
+func rehearseConflict(port int, policy killPolicy) {
+	killer := newDryRunKiller("guard")
+	holders, err := killHolders(defaultScanner, killer, port, policy, promptConfirm)
+	if err != nil && !errors.Is(err, errAborted) {
+		eprintf("%v", err)
+	}
+	for _, p := range holders {
+		eprintf("would kill %s (pid %d) with %s and restart (dry run)", processLabel(p), p.PID, killDescription(killer, p))
+	}
+}
+
+// parseAddrInUse reports whether a line of output is an "address already in
+// use" error and, if the line names it, the conflicting port.
+func parseAddrInUse(line string) (inUse bool, port int) {
+	if !addrInUseRegex.MatchString(line) {
+		return false, 0
+	}
+	return true, extractPort(line)
+}
+
+// extractPort returns the port named in a line of output, or 0 if there is none.
+// When a pattern matches several times the last match wins, which skips
+// timestamps at the start of log lines.
+func extractPort(line string) int {
+	for _, re := range portExtractRegex {
+		matches := re.FindAllStringSubmatch(line, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		port, err := strconv.Atoi(matches[len(matches)-1][1])
+		if err == nil && port > 0 && port <= 65535 {
+			return port
+		}
+	}
+	return 0
+}
+
+// addrInUseWatcher is an io.Writer that passes output through unchanged while
+// looking for "address already in use" errors line by line.
+type addrInUseWatcher struct {
+	out io.Writer
+
+	mu        sync.Mutex
+	line      []byte
+	inUse     bool
+	port      int
+	lookahead int           // lines left in which a port may follow an error without one
+	detected  chan struct{} // closed when the first error is seen
+}
+
+// newAddrInUseWatcher returns a watcher that copies everything written to it to out
+func newAddrInUseWatcher(out io.Writer) *addrInUseWatcher {
+	return &addrInUseWatcher{out: out, detected: make(chan struct{})}
+}
+
+// Detected returns a channel that is closed once an "address already in use"
+// error has been seen
+func (w *addrInUseWatcher) Detected() <-chan struct{} {
+	return w.detected
+}
+
+// Write implements io.Writer
+func (w *addrInUseWatcher) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, b := range p {
+		if b == '\n' {
+			w.scanLine()
+			continue
+		}
+		if len(w.line) < maxLineLength {
+			w.line = append(w.line, b)
+		}
+	}
+	return w.out.Write(p)
+}
+
+// Flush matches any trailing output that did not end in a newline
+func (w *addrInUseWatcher) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.line) > 0 {
+		w.scanLine()
+	}
+}
+
+// Conflict reports whether an "address already in use" error was seen and the
+// port it named, if any.
+func (w *addrInUseWatcher) Conflict() (bool, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.inUse, w.port
+}
+
+// scanLine matches the buffered line and resets the buffer. Callers hold w.mu.
+func (w *addrInUseWatcher) scanLine() {
+	line := string(bytes.TrimRight(w.line, "\r"))
+	w.line = w.line[:0]
+
+	if inUse, port := parseAddrInUse(line); inUse {
+		if !w.inUse {
+			close(w.detected)
+		}
+		w.inUse = true
+		if port != 0 {
+			w.port = port
+		}
+		w.lookahead = portLookahead
+		return
+	}
+
+	// Some servers name the port on a following line (e.g. Flask)
+	if w.inUse && w.port == 0 && w.lookahead > 0 {
+		w.lookahead--
+		if port := portWordRegex.FindStringSubmatch(line); port != nil {
+			w.port, _ = strconv.Atoi(port[1])
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseAddrInUse(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantInUse bool
+		wantPort  int
+	}{
+		{"node", "Error: listen EADDRINUSE: address already in use :::3000", true, 3000},
+		{"node ipv4", "Error: listen EADDRINUSE: address already in use 127.0.0.1:5173", true, 5173},
+		{"node code", "  code: 'EADDRINUSE',", true, 0},
+		{"go", "2024/01/02 10:11:12 listen tcp :8080: bind: address already in use", true, 8080},
+		{"go with host", "listen tcp 127.0.0.1:9000: bind: address already in use", true, 9000},
+		{"python uvicorn", "ERROR:    [Errno 98] error while attempting to bind on address ('127.0.0.1', 8000): address already in use", true, 8000},
+		{"python bare", "OSError: [Errno 48] Address already in use", true, 0},
+		{"flask", "Port 5000 is in use by another program. Either identify and stop that program, or start the server with a different port.", true, 5000},
+		{"rust", `Error: Os { code: 98, kind: AddrInUse, message: "Address already in use" }`, true, 0},
+		{"rust ipv6", "thread 'main' panicked: error binding to [::1]:4000: Address already in use (os error 98)", true, 4000},
+		{"java", "java.net.BindException: Address already in use", true, 0},
+		{"spring boot", "Web server failed to start. Port 8080 was already in use.", true, 8080},
+		{"unrelated", "Server listening on http://localhost:3000", false, 0},
+		{"empty", "", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inUse, port := parseAddrInUse(tt.line)
+			if inUse != tt.wantInUse || port != tt.wantPort {
+				t.Errorf("parseAddrInUse(%q) = (%v, %d), expected (%v, %d)", tt.line, inUse, port, tt.wantInUse, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestAddrInUseWatcher(t *testing.T) {
+	var out strings.Builder
+	w := newAddrInUseWatcher(&out)
+
+	// Written in chunks that split lines, as a pipe may deliver them
+	chunks := []string{
+		"starting server\nOSError: [Errno 98] Addr",
+		"ess already in use\n",
+		"Port 5000 is in use by another program.",
+	}
+	for _, chunk := range chunks {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if inUse, port := w.Conflict(); !inUse || port != 0 {
+		t.Errorf("before flush: Conflict() = (%v, %d), expected (true, 0)", inUse, port)
+	}
+	w.Flush()
+	if inUse, port := w.Conflict(); !inUse || port != 5000 {
+		t.Errorf("after flush: Conflict() = (%v, %d), expected (true, 5000)", inUse, port)
+	}
+	if out.String() != strings.Join(chunks, "") {
+		t.Errorf("output was not passed through unchanged: %q", out.String())
+	}
+}
+
+func TestWatchChildDetectsConflictWhileRunning(t *testing.T) {
+	// A server that logs the error and keeps retrying instead of exiting
+	argv := []string{"sh", "-c", `echo "Error: listen EADDRINUSE: address already in use :::3000" >&2; exec sleep 30`}
+	var out strings.Builder
+	watcher := newAddrInUseWatcher(&out)
+	c, err := startChild(argv, nil, watcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	run := watchChild(c, watcher)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the conflict to be seen while the command runs, took %s", elapsed)
+	}
+	if inUse, port := watcher.Conflict(); !inUse || port != 3000 || run.done {
+		t.Errorf("Conflict() = (%v, %d) with done %v, expected (true, 3000) while running", inUse, port, run.done)
+	}
+
+	run.stop()
+	if code := run.wait(); code != 128+int(syscall.SIGTERM) {
+		t.Errorf("expected the command to be stopped with SIGTERM, got exit code %d", code)
+	}
+}
+
+func TestGuardedRunStopsProcessGroup(t *testing.T) {
+	// A script that, like npm run dev, leaves the listening work to a child
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	argv := []string{"sh", "-c", `sleep 30 & echo $! > ` + pidFile + `; echo "address already in use :3000" >&2; wait`}
+	watcher := newAddrInUseWatcher(io.Discard)
+	c, err := startChildGroup(argv, nil, watcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := watchChild(c, watcher)
+	if run.done {
+		t.Fatal("expected the command to still be running")
+	}
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+
+	start := time.Now()
+	run.stop()
+	// The grandchild holds the stderr pipe open, so the command only counts as
+	// stopped once it is gone too
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the command to stop promptly, took %s", elapsed)
+	}
+	for deadline := time.Now().Add(5 * time.Second); syscall.Kill(pid, 0) == nil; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the grandchild %d to be stopped with the command", pid)
+		}
+	}
+}
@@ -1,13 +1,27 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
 
-// truncate truncates a string to maxLen, padding with spaces if shorter
+	"github.com/charmbracelet/x/ansi"
+)
+
+// truncate truncates a string to maxLen terminal cells, padding with spaces if shorter.
+// Wide characters such as CJK and emoji count as two cells.
 func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return fmt.Sprintf("%-*s", maxLen, s)
+	if ansi.StringWidth(s) > maxLen {
+		s = ansi.Truncate(s, maxLen, "…")
+	}
+	return s + strings.Repeat(" ", max(0, maxLen-ansi.StringWidth(s)))
+}
+
+// pluralize returns "1 process" or "2 processes" style counts
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
 	}
-	return s[:maxLen-1] + "…"
+	return fmt.Sprintf("%d %s", n, plural)
 }
 
 // formatPorts formats a list of ports for display with truncation.
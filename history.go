@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// historyEntry is a single kill attempt recorded in the audit log
+type historyEntry struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`   // who sent the kill
+	Source    string    `json:"source"` // which part of portsweep sent it, e.g. "tui" or "exec"
+	PID       int       `json:"pid"`
+	StartTime time.Time `json:"start_time,omitzero"`
+	Ports     []int     `json:"ports"`
+	Command   string    `json:"command"`
+	Cwd       string    `json:"cwd,omitempty"`
+	Signal    string    `json:"signal"` // signal or shutdown strategy used
+	Result    string    `json:"result"` // "sent" or "failed"
+	Error     string    `json:"error,omitempty"`
+	Errno     int       `json:"errno,omitempty"`
+}
+
+// stateDir returns the directory portsweep keeps its state in, following the
+// XDG base directory spec
+func stateDir() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "portsweep"), nil
+}
+
+// historyPath returns the audit log location
+func historyPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// AuditKiller implements ProcessKiller by delegating to another killer and
+// appending every attempt to the audit log. Failing to write the log does
+// not stop the kill, but is reported.
+type AuditKiller struct {
+	Killer ProcessKiller
+	Source string // which part of portsweep sends the kills
+	Path   string // audit log to append to; empty for historyPath()
+}
+
+// auditedKiller wraps a killer so its kills are recorded in the audit log
+func auditedKiller(k ProcessKiller, source string) *AuditKiller {
+	return &AuditKiller{Killer: k, Source: source}
+}
+
+// Kill looks the process up and kills it as KillTarget does. Callers that
+// already know the process should use KillTarget.
+func (k *AuditKiller) Kill(pid int) error {
+	p, err := lookupListener(pid)
+	if err != nil {
+		p = Process{PID: pid}
+	}
+	return k.KillTarget(killTarget{Process: p})
+}
+
+// KillTarget kills the process and appends the outcome to the audit log. A
+// failure to write the log is reported on stderr.
+func (k *AuditKiller) KillTarget(t killTarget) error {
+	err, logErr := k.killAndRecord(t)
+	if logErr != nil {
+		eprintf("recording kill in audit log: %v", logErr)
+	}
+	return err
+}
+
+// killAndRecord kills the process and appends the outcome to the audit log,
+// returning the error of the kill and of writing the log separately
+func (k *AuditKiller) killAndRecord(t killTarget) (err, logErr error) {
+	// Details have to be captured before the process is gone
+	entry := describeKill(t)
+	entry.Source = k.Source
+	entry.Signal = killDescription(k.Killer, t.Process)
+
+	err = sendKill(k.Killer, t)
+	entry.Time = time.Now()
+	entry.Result = "sent"
+	if err != nil {
+		if !killSent(err) {
+			entry.Result = "failed"
+		}
+		entry.Error = err.Error()
+		var errno syscall.Errno
+		if errors.As(err, &errno) {
+			entry.Errno = int(errno)
+		}
+	}
+
+	path := k.Path
+	if path == "" {
+		if path, logErr = historyPath(); logErr != nil {
+			return err, logErr
+		}
+	}
+	return err, appendHistory(path, entry)
+}
+
+// killAndAudit kills a process and, if the killer keeps an audit log, returns
+// the error of writing it separately instead of reporting it on stderr
+func killAndAudit(killer ProcessKiller, t killTarget) (err, logErr error) {
+	if k, ok := killer.(*AuditKiller); ok {
+		return k.killAndRecord(t)
+	}
+	return sendKill(killer, t), nil
+}
+
+// describeKill collects what the audit log records about a process about to
+// be killed. Only the start time, if the caller does not know it, and the
+// working directory are looked up.
+func describeKill(t killTarget) historyEntry {
+	entry := historyEntry{User: currentUsername(), PID: t.PID, Ports: []int{}, Command: t.Command, StartTime: t.StartTime}
+	if t.Ports != nil {
+		entry.Ports = t.Ports
+	}
+	if entry.StartTime.IsZero() {
+		entry.StartTime, _ = processStartTime(t.PID)
+	}
+	entry.Cwd, _ = processCwd(t.PID)
+	return entry
+}
+
+// appendHistory appends an entry to the audit log as a single JSON line
+func appendHistory(path string, entry historyEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// readHistory parses an audit log. Lines that are not valid entries, such as
+// a line cut short by a crash, are skipped. Lines have no length limit, as
+// every entry holds the full command line.
+func readHistory(r io.Reader) ([]historyEntry, error) {
+	var entries []historyEntry
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		var e historyEntry
+		if len(line) > 0 && json.Unmarshal(line, &e) == nil {
+			entries = append(entries, e)
+		}
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+	}
+}
+
+// filterHistory returns the entries at or after since (if set) that involve port (if set)
+func filterHistory(entries []historyEntry, since time.Time, port int) []historyEntry {
+	var result []historyEntry
+	for _, e := range entries {
+		if !since.IsZero() && e.Time.Before(since) {
+			continue
+		}
+		if port != 0 && !slices.Contains(e.Ports, port) {
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// historyOptions holds the flags for the history subcommand
+type historyOptions struct {
+	since time.Duration
+	port  int
+	json  bool
+}
+
+// newHistoryFlags returns the flag set for the history subcommand bound to opts
+func newHistoryFlags(opts *historyOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.DurationVar(&opts.since, "since", 0, "only show kills within this long ago (e.g. 1h)")
+	fs.IntVar(&opts.port, "port", 0, "only show kills of processes listening on this port")
+	fs.BoolVar(&opts.json, "json", false, "print entries as newline-delimited JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: portsweep history [--since 1h] [--port 3000] [--json]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Show kills recorded in the audit log.")
+		if path, err := historyPath(); err == nil {
+			fmt.Fprintf(fs.Output(), "The log is %s.\n", path)
+		}
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runHistory implements `portsweep history`
+func runHistory(args []string) int {
+	opts := historyOptions{}
+	fs := newHistoryFlags(&opts)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 || opts.since < 0 || opts.port < 0 {
+		fs.Usage()
+		return 2
+	}
+
+	path, err := historyPath()
+	if err != nil {
+		eprintf("%v", err)
+		return 1
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0
+	}
+	if err != nil {
+		eprintf("%v", err)
+		return 1
+	}
+	defer f.Close()
+
+	entries, err := readHistory(f)
+	if err != nil {
+		eprintf("reading %s: %v", path, err)
+		return 1
+	}
+	var since time.Time
+	if opts.since > 0 {
+		since = time.Now().Add(-opts.since)
+	}
+	entries = filterHistory(entries, since, opts.port)
+
+	if opts.json {
+		for _, e := range entries {
+			data, _ := json.Marshal(e)
+			fmt.Printf("%s\n", data)
+		}
+		return 0
+	}
+	if err := printHistory(os.Stdout, entries); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// printHistory writes audit log entries as a tab-aligned table
+func printHistory(w io.Writer, entries []historyEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tUSER\tSOURCE\tPORT\tPID\tSIGNAL\tRESULT\tCOMMAND")
+	for _, e := range entries {
+		ports := make([]string, len(e.Ports))
+		for i, port := range e.Ports {
+			ports[i] = strconv.Itoa(port)
+		}
+		result := e.Result
+		if e.Error != "" {
+			result += ": " + e.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			e.Time.Local().Format(time.DateTime), e.User, e.Source, strings.Join(ports, ","),
+			e.PID, e.Signal, result, formatCommand(e.Command))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// sigintKiller is a MockKiller whose kills are described as SIGINT
+type sigintKiller struct {
+	MockKiller
+}
+
+func (*sigintKiller) StrategyFor(Process) shutdownStrategy {
+	return shutdownStrategy{Action: actionSignal, Signal: syscall.SIGINT}
+}
+
+func TestAuditKiller(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portsweep", "history.jsonl")
+
+	ok := &AuditKiller{Killer: &MockKiller{}, Source: "test", Path: path}
+	if err := ok.Kill(os.Getpid()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	failing := &AuditKiller{Killer: &sigintKiller{MockKiller{Err: syscall.EPERM}}, Source: "test", Path: path}
+	if err := failing.Kill(os.Getpid()); !errors.Is(err, syscall.EPERM) {
+		t.Fatalf("expected the killer's error, got %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("audit log not written: %v", err)
+	}
+	defer f.Close()
+	entries, err := readHistory(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.PID != os.Getpid() || first.Source != "test" || first.Signal != "SIGTERM" || first.Result != "sent" {
+		t.Errorf("unexpected entry %+v", first)
+	}
+	if wd, _ := os.Getwd(); first.Cwd != "" && first.Cwd != wd {
+		t.Errorf("cwd = %q, expected %q", first.Cwd, wd)
+	}
+
+	second := entries[1]
+	if second.Result != "failed" || second.Signal != "SIGINT" || second.Errno != int(syscall.EPERM) {
+		t.Errorf("unexpected entry %+v", second)
+	}
+}
+
+func TestAuditKillerUsesCallerDetails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	k := &AuditKiller{Killer: &MockKiller{}, Source: "test", Path: path}
+	started := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	target := killTarget{Process: Process{PID: os.Getpid(), Ports: []int{3000}, Command: "node server.js"}, StartTime: started}
+
+	if err, logErr := k.killAndRecord(target); err != nil || logErr != nil {
+		t.Fatalf("killAndRecord() = %v, %v", err, logErr)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := readHistory(strings.NewReader(string(data)))
+	if len(entries) != 1 || entries[0].Command != "node server.js" || !entries[0].StartTime.Equal(started) || !slices.Equal(entries[0].Ports, []int{3000}) {
+		t.Errorf("expected the caller's details to be recorded, got %+v", entries)
+	}
+
+	// A log that cannot be written is reported, and the kill still happens
+	killer := &MockKiller{}
+	blocked := &AuditKiller{Killer: killer, Source: "test", Path: filepath.Join(path, "history.jsonl")}
+	err, logErr := blocked.killAndRecord(target)
+	if err != nil || logErr == nil || len(killer.KilledPIDs) != 1 {
+		t.Errorf("expected the kill to be sent and the log error returned, got %v, %v", err, logErr)
+	}
+}
+
+func TestReadHistory(t *testing.T) {
+	log := `{"time":"2026-10-18T10:00:00Z","user":"alice","pid":1,"ports":[3000],"signal":"SIGTERM","result":"sent"}
+{"time":"2026-10-18T11:00:00Z","user":"bo
+{"time":"2026-10-18T12:00:00Z","user":"bob","pid":2,"ports":[5432,5433],"signal":"SIGINT","result":"sent"}
+`
+	entries, err := readHistory(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].User != "alice" || entries[1].User != "bob" {
+		t.Fatalf("expected the truncated line to be skipped, got %+v", entries)
+	}
+
+	// Commands such as a long Java classpath make lines longer than a scanner buffer
+	long := `{"pid":3,"command":"java -cp ` + strings.Repeat("a.jar:", 20000) + `"}` + "\n" + log
+	if entries, err := readHistory(strings.NewReader(long)); err != nil || len(entries) != 3 || entries[0].PID != 3 {
+		t.Fatalf("expected the long line to be read, got %d entries, %v", len(entries), err)
+	}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		port  int
+		want  []int // PIDs
+	}{
+		{"everything", time.Time{}, 0, []int{1, 2}},
+		{"since", time.Date(2026, 10, 18, 11, 0, 0, 0, time.UTC), 0, []int{2}},
+		{"port", time.Time{}, 5433, []int{2}},
+		{"no match", time.Time{}, 8080, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, e := range filterHistory(entries, tt.since, tt.port) {
+				got = append(got, e.PID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got PIDs %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -15,6 +15,24 @@ type keyMap struct {
 	Select    key.Binding
 	SelectAll key.Binding
 	Search    key.Binding
+	DryRun    key.Binding
+	Undo      key.Binding
+	Restart   key.Binding
+	Recent    key.Binding
+	PageUp    key.Binding
+	PageDown  key.Binding
+	Home      key.Binding
+	End       key.Binding
+	Sort      key.Binding
+	Reverse   key.Binding
+	Fuzzy     key.Binding
+	Columns   key.Binding
+	MoveUp    key.Binding
+	MoveDown  key.Binding
+	Detail    key.Binding
+	PaneUp    key.Binding
+	PaneDown  key.Binding
+	Listeners key.Binding
 }
 
 // keys is the default set of key bindings
@@ -63,4 +81,76 @@ var keys = keyMap{
 		key.WithKeys("/"),
 		key.WithHelp("/", "search"),
 	),
+	DryRun: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "toggle dry run"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u", "esc"),
+		key.WithHelp("u/esc", "cancel pending kill"),
+	),
+	Restart: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "restart"),
+	),
+	Recent: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "recently killed"),
+	),
+	PageUp: key.NewBinding(
+		key.WithKeys("pgup", "ctrl+u"),
+		key.WithHelp("pgup", "page up"),
+	),
+	PageDown: key.NewBinding(
+		key.WithKeys("pgdown", "ctrl+d"),
+		key.WithHelp("pgdn", "page down"),
+	),
+	Home: key.NewBinding(
+		key.WithKeys("home", "g"),
+		key.WithHelp("home/g", "first"),
+	),
+	End: key.NewBinding(
+		key.WithKeys("end", "G"),
+		key.WithHelp("end/G", "last"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "sort by next column"),
+	),
+	Reverse: key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "reverse sort"),
+	),
+	Fuzzy: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "toggle fuzzy search"),
+	),
+	Columns: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "choose columns"),
+	),
+	MoveUp: key.NewBinding(
+		key.WithKeys("K", "shift+up"),
+		key.WithHelp("K", "move column up"),
+	),
+	MoveDown: key.NewBinding(
+		key.WithKeys("J", "shift+down"),
+		key.WithHelp("J", "move column down"),
+	),
+	Detail: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "toggle details"),
+	),
+	PaneUp: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "scroll details up"),
+	),
+	PaneDown: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "scroll details down"),
+	),
+	Listeners: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "toggle one row per listener"),
+	),
 }
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// leakCheckOptions holds the flags for the leak-check subcommand
+type leakCheckOptions struct {
+	kill   bool
+	dryRun bool
+}
+
+// newLeakCheckFlags returns the flag set for the leak-check subcommand bound to opts
+func newLeakCheckFlags(opts *leakCheckOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("leak-check", flag.ContinueOnError)
+	fs.BoolVar(&opts.kill, "kill", false, "kill leaked processes")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "with --kill, report what would be killed without sending signals")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: portsweep leak-check [--kill [--dry-run]] -- <command> [args...]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Run the command and report listeners it left behind: new listeners owned by one of its")
+		fmt.Fprintln(fs.Output(), "descendants or by a process started while it ran. Exits non-zero when anything leaked.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// leak is a process that was left listening after the checked command exited
+type leak struct {
+	Process
+	NewPorts []int  // ports that were not held before the command ran
+	Reason   string // why the process counts as leaked
+}
+
+// runLeakCheck implements `portsweep leak-check`
+func runLeakCheck(args []string) int {
+	opts := leakCheckOptions{}
+	fs := newLeakCheckFlags(&opts)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	argv := fs.Args()
+	if len(argv) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	before, err := defaultScanner.GetListeningPorts()
+	if err != nil {
+		eprintf("scanning ports: %v", err)
+		return 1
+	}
+
+	started := time.Now()
+	c, err := startChild(argv, nil, nil)
+	if err != nil {
+		eprintf("%v", err)
+		return 127
+	}
+
+	// Descendants are reparented once the command exits, so record them while it runs
+	done := make(chan struct{})
+	descendants := make(chan map[int]bool)
+	go func() {
+		descendants <- trackDescendants(c.PID(), done)
+	}()
+
+	code := c.Wait()
+	close(done)
+	seen := <-descendants
+
+	after, err := defaultScanner.GetListeningPorts()
+	if err != nil {
+		eprintf("scanning ports: %v", err)
+		return 1
+	}
+	table, err := readProcessTable()
+	if err != nil {
+		eprintf("reading process table: %v", err)
+		return 1
+	}
+
+	leaks := findLeaks(before, after, seen, table, started)
+	if len(leaks) == 0 {
+		return code
+	}
+
+	printLeaks(os.Stderr, strings.Join(argv, " "), leaks)
+	if opts.kill && opts.dryRun {
+		for _, l := range leaks {
+			eprintf("would kill process %d (dry run)", l.PID)
+		}
+	} else if opts.kill {
+		killer := newKiller("leak-check")
+		for _, l := range leaks {
+			switch err := sendKill(killer, killTarget{Process: l.Process, StartTime: table[l.PID].StartTime}); {
+			case !killSent(err):
+				eprintf("failed to kill process %d: %v", l.PID, err)
+			case err != nil:
+				eprintf("killed process %d (%v)", l.PID, err)
+			default:
+				eprintf("killed process %d", l.PID)
+			}
+		}
+	}
+
+	if code != 0 {
+		return code
+	}
+	return 1
+}
+
+// trackDescendants polls the process table until done is closed and returns
+// every PID that was seen as pid or one of its descendants.
+func trackDescendants(pid int, done <-chan struct{}) map[int]bool {
+	seen := map[int]bool{pid: true}
+	ticker := time.NewTicker(ListenPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if table, err := readProcessTable(); err == nil {
+			for _, d := range descendantsOf(table, pid) {
+				seen[d] = true
+			}
+		}
+
+		select {
+		case <-done:
+			return seen
+		case <-ticker.C:
+		}
+	}
+}
+
+// findLeaks compares the listeners before and after a command ran. A new
+// listener counts as leaked when its process was seen as a descendant of the
+// command, or when the process table says it started during the run.
+func findLeaks(before, after []Process, descendants map[int]bool, table map[int]procInfo, started time.Time) []leak {
+	held := make(map[int]map[int]bool) // PID -> ports held before
+	for _, p := range before {
+		held[p.PID] = make(map[int]bool)
+		for _, port := range p.Ports {
+			held[p.PID][port] = true
+		}
+	}
+
+	// lstart has one second resolution
+	since := started.Truncate(time.Second)
+
+	var leaks []leak
+	for _, p := range after {
+		var newPorts []int
+		for _, port := range p.Ports {
+			if !held[p.PID][port] {
+				newPorts = append(newPorts, port)
+			}
+		}
+		if len(newPorts) == 0 {
+			continue
+		}
+
+		l := leak{Process: p, NewPorts: newPorts}
+		info, known := table[p.PID]
+		switch {
+		case descendants[p.PID]:
+			l.Reason = "child of the command"
+		case known && !info.StartTime.IsZero() && !info.StartTime.Before(since):
+			l.Reason = "started during the run"
+		default:
+			continue
+		}
+		leaks = append(leaks, l)
+	}
+
+	sort.Slice(leaks, func(i, j int) bool {
+		return leaks[i].NewPorts[0] < leaks[j].NewPorts[0]
+	})
+	return leaks
+}
+
+// printLeaks writes a report of leaked listeners
+func printLeaks(w io.Writer, command string, leaks []leak) {
+	fmt.Fprintf(w, "portsweep: %s left listening after `%s`:\n", pluralize(len(leaks), "process", "processes"), command)
+	for _, l := range leaks {
+		fmt.Fprintf(w, "  %-18s pid %-8d %-30s %s\n", formatPorts(l.NewPorts, 18), l.PID, processLabel(l.Process), l.Reason)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindLeaks(t *testing.T) {
+	started := time.Date(2026, 10, 18, 10, 0, 0, 500, time.Local)
+	earlier := started.Add(-time.Hour)
+
+	before := []Process{
+		{PID: 10, Ports: []int{5432}, Name: "postgres"},
+		{PID: 11, Ports: []int{8080}, Name: "nginx"},
+	}
+	after := []Process{
+		{PID: 10, Ports: []int{5432}, Name: "postgres"},       // unchanged
+		{PID: 11, Ports: []int{8080, 8443}, Name: "nginx"},    // new port, but old process
+		{PID: 20, Ports: []int{3000}, Name: "node"},           // descendant of the command
+		{PID: 21, Ports: []int{4000, 4001}, Name: "orphan"},   // reparented, started during the run
+		{PID: 22, Ports: []int{9000}, Name: "preexisting"},    // started before the run
+		{PID: 23, Ports: []int{9100}, Name: "no-table-entry"}, // unknown to the process table
+	}
+	descendants := map[int]bool{1: true, 20: true}
+	table := map[int]procInfo{
+		10: {PID: 10, PPID: 1, StartTime: earlier},
+		11: {PID: 11, PPID: 1, StartTime: earlier},
+		20: {PID: 20, PPID: 1, StartTime: started},
+		21: {PID: 21, PPID: 1, StartTime: started.Truncate(time.Second)},
+		22: {PID: 22, PPID: 1, StartTime: earlier},
+	}
+
+	leaks := findLeaks(before, after, descendants, table, started)
+	if len(leaks) != 2 {
+		t.Fatalf("expected 2 leaks, got %d: %+v", len(leaks), leaks)
+	}
+	if leaks[0].PID != 20 || leaks[0].Reason != "child of the command" {
+		t.Errorf("unexpected first leak: %+v", leaks[0])
+	}
+	if leaks[1].PID != 21 || leaks[1].Reason != "started during the run" {
+		t.Errorf("unexpected second leak: %+v", leaks[1])
+	}
+	if len(leaks[1].NewPorts) != 2 {
+		t.Errorf("expected both new ports of PID 21, got %v", leaks[1].NewPorts)
+	}
+}
+
+func TestParsePsTable(t *testing.T) {
+	table := parsePsTable(`    1     0  1024  0.0 Sat Oct 18 09:00:00 2026
+  123     1 52344 12.5 Sun Oct  5 10:11:12 2026
+  bad line
+`)
+	if len(table) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(table))
+	}
+	got := table[123]
+	want := time.Date(2026, 10, 5, 10, 11, 12, 0, time.Local)
+	if got.PPID != 1 || got.RSS != 52344 || got.CPU != 12.5 || !got.StartTime.Equal(want) {
+		t.Errorf("unexpected entry for PID 123: %+v", got)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+// rowKey identifies a row of the process list across refreshes
+type rowKey struct {
+	PID      int
+	Listener Listener // zero unless the row is a listener in the listener view
+}
+
+// processKey identifies a row showing a whole process
+func processKey(p Process) rowKey {
+	return rowKey{PID: p.PID}
+}
+
+// listenerKey identifies a row showing a single listener of a process
+func listenerKey(p Process) rowKey {
+	key := rowKey{PID: p.PID}
+	if len(p.Listeners) > 0 {
+		key.Listener = p.Listeners[0]
+	}
+	return key
+}
+
+// listenerRows returns one row per listening socket: a copy of its process
+// with only that listener and its port
+func listenerRows(processes []Process) []Process {
+	var rows []Process
+	for _, p := range processes {
+		listeners := p.Listeners
+		if len(listeners) == 0 {
+			// Without socket details there is still a row per port
+			for _, port := range p.Ports {
+				listeners = append(listeners, Listener{Port: port})
+			}
+		}
+		for _, l := range listeners {
+			row := p
+			row.Ports = []int{l.Port}
+			row.Listeners = []Listener{l}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// uniqueProcesses returns the processes the rows belong to, each once, in
+// the order they first appear. Rows are looked up in processes by PID so
+// listener rows turn back into their whole process.
+func uniqueProcesses(rows, processes []Process) []Process {
+	byPID := make(map[int]Process, len(processes))
+	for _, p := range processes {
+		byPID[p.PID] = p
+	}
+	seen := make(map[int]bool)
+	var result []Process
+	for _, row := range rows {
+		if seen[row.PID] {
+			continue
+		}
+		seen[row.PID] = true
+		if p, ok := byPID[row.PID]; ok {
+			row = p
+		}
+		result = append(result, row)
+	}
+	return result
+}
+
+// listenerCount returns how many listeners the processes have in total
+func listenerCount(processes []Process) int {
+	count := 0
+	for _, p := range processes {
+		count += max(len(p.Listeners), len(p.Ports))
+	}
+	return count
+}
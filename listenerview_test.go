@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListenerRows(t *testing.T) {
+	processes := []Process{
+		{PID: 1, Name: "node", Ports: []int{3000, 3001}, Listeners: []Listener{
+			{Address: "127.0.0.1", Port: 3000, Protocol: "tcp4"},
+			{Address: "::1", Port: 3000, Protocol: "tcp6"},
+			{Address: "127.0.0.1", Port: 3001, Protocol: "tcp4"},
+		}},
+		// Without socket details each port still gets a row
+		{PID: 2, Name: "api", Ports: []int{8080, 8081}},
+	}
+	rows := listenerRows(processes)
+
+	var got []rowKey
+	for _, r := range rows {
+		if len(r.Ports) != 1 || len(r.Listeners) != 1 || r.Ports[0] != r.Listeners[0].Port {
+			t.Errorf("expected a single listener and its port per row, got %+v", r)
+		}
+		got = append(got, listenerKey(r))
+	}
+	want := []rowKey{
+		{1, Listener{Address: "127.0.0.1", Port: 3000, Protocol: "tcp4"}},
+		{1, Listener{Address: "::1", Port: 3000, Protocol: "tcp6"}},
+		{1, Listener{Address: "127.0.0.1", Port: 3001, Protocol: "tcp4"}},
+		{2, Listener{Port: 8080}},
+		{2, Listener{Port: 8081}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("listenerRows() keys = %v, expected %v", got, want)
+	}
+	if len(processes[0].Listeners) != 3 {
+		t.Error("expected the processes to be left untouched")
+	}
+}
+
+func TestUniqueProcesses(t *testing.T) {
+	processes := []Process{
+		{PID: 1, Ports: []int{3000, 3001}},
+		{PID: 2, Ports: []int{8080}},
+	}
+	rows := listenerRows(processes)
+	got := uniqueProcesses([]Process{rows[2], rows[0], rows[1]}, processes)
+	if want := []Process{processes[1], processes[0]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("uniqueProcesses() = %v, expected %v", got, want)
+	}
+	if n := listenerCount(got); n != 3 {
+		t.Errorf("listenerCount() = %d, expected 3", n)
+	}
+}
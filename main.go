@@ -1,8 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -10,12 +14,175 @@ import (
 // version is set at build time via ldflags
 var version = "dev"
 
-func main() {
-	var initialFilter string
+// command is a subcommand that runs without the TUI
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int // returns the process exit code
+	flags   func() *flag.FlagSet    // the command's flags, for completion; nil if it has none
+	args    []string                // fixed values of the first positional argument, for completion
+	hidden  bool                    // whether to leave the command out of help and completion
+}
+
+// subcommands returns all available subcommands in the order they are listed in help
+func subcommands() []command {
+	return []command{
+		{
+			name: "exec", summary: "Clear a port, then run a command", run: runExec,
+			flags: func() *flag.FlagSet { return newExecFlags(&execOptions{}) },
+		},
+		{
+			name: "restart", summary: "Kill the process on a port and start it again", run: runRestart,
+			flags: func() *flag.FlagSet { return newRestartFlags(&restartOptions{}) },
+		},
+		{
+			name: "run", summary: "Run a command on an automatically chosen free port", run: runRun,
+			flags: func() *flag.FlagSet { return newRunFlags(&runOptions{}) },
+		},
+		{
+			name: "guard", summary: "Run a command and resolve \"address already in use\" errors", run: runGuard,
+			flags: func() *flag.FlagSet { return newGuardFlags(&guardOptions{}) },
+		},
+		{
+			name: "leak-check", summary: "Report listeners a command leaves running", run: runLeakCheck,
+			flags: func() *flag.FlagSet { return newLeakCheckFlags(&leakCheckOptions{}) },
+		},
+		{
+			name: "snapshot", summary: "Save listeners to a file or compare snapshots", run: runSnapshot,
+			args: []string{"save", "diff"},
+		},
+		{
+			name: "watch", summary: "Print an event whenever a listener opens, closes or changes owner", run: runWatch,
+			flags: func() *flag.FlagSet { return newWatchFlags(&watchOptions{}) },
+		},
+		{
+			name: "plan", summary: "Write a reviewable plan of processes to kill", run: runPlan,
+			flags: func() *flag.FlagSet { return newPlanFlags(&planOptions{}) },
+		},
+		{
+			name: "apply", summary: "Verify and signal the targets of a plan", run: runApply,
+			flags: func() *flag.FlagSet { return newApplyFlags(&applyOptions{}) },
+		},
+		{
+			name: "history", summary: "Show kills recorded in the audit log", run: runHistory,
+			flags: func() *flag.FlagSet { return newHistoryFlags(&historyOptions{}) },
+		},
+		{
+			name: "completion", summary: "Print a shell completion script", run: runCompletion,
+			args: completionShells,
+		},
+		{
+			name: "__complete", summary: "Print completion candidates for the shell scripts", run: runComplete,
+			hidden: true,
+		},
+	}
+}
+
+// findCommand returns the subcommand with the given name, or nil if there is none
+func findCommand(name string) *command {
+	for _, cmd := range subcommands() {
+		if cmd.name == name {
+			return &cmd
+		}
+	}
+	return nil
+}
+
+// DefaultInlineHeight is the number of process rows shown in inline mode
+const DefaultInlineHeight = 15
+
+// rootOptions holds the flags and filter argument of the interactive mode
+type rootOptions struct {
+	filter    string
+	inline    bool
+	height    int
+	dryRun    bool
+	killDelay time.Duration
+	columns   []columnID
+}
 
-	// Handle flags and arguments
+// newRootFlags returns the flag set of the interactive mode bound to opts
+func newRootFlags(opts *rootOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("portsweep", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&opts.inline, "inline", false, "render in the normal screen instead of the alternate screen")
+	fs.IntVar(&opts.height, "height", DefaultInlineHeight, "maximum number of process rows in inline mode")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "record kills instead of sending signals")
+	fs.DurationVar(&opts.killDelay, "kill-delay", 0, "wait this long after confirming before killing, to allow undo")
+	fs.Func("columns", "comma-separated columns to show, in order", func(s string) error {
+		columns, err := parseColumns(s)
+		opts.columns = columns
+		return err
+	})
+	return fs
+}
+
+// parseRootArgs parses the flags and filter argument of the interactive mode.
+// Flags may appear before or after the filter. A filter starting with a
+// negated term such as -user:root is not mistaken for a flag, and everything
+// after -- is a filter.
+func parseRootArgs(args []string) (rootOptions, error) {
+	opts := rootOptions{}
+	fs := newRootFlags(&opts)
+
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			positional = append(positional, arg)
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil && !strings.HasPrefix(arg, "--") && name != "h" && name != "help" {
+			positional = append(positional, arg)
+			continue
+		}
+		flags = append(flags, arg)
+		if f != nil && !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	if err := fs.Parse(flags); err != nil {
+		return opts, err
+	}
+
+	if len(positional) > 1 {
+		return opts, fmt.Errorf("unexpected argument %q", positional[1])
+	}
+	if len(positional) == 1 {
+		opts.filter = positional[0]
+		if _, err := parseQuery(opts.filter); err != nil {
+			return opts, fmt.Errorf("invalid filter %q: %w", opts.filter, err)
+		}
+	}
+	if opts.height < 1 {
+		return opts, fmt.Errorf("invalid height %d", opts.height)
+	}
+	if opts.killDelay < 0 {
+		return opts, fmt.Errorf("invalid kill delay %s", opts.killDelay)
+	}
+	return opts, nil
+}
+
+// isBoolFlag reports whether a flag takes no value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func main() {
+	// Handle subcommands and informational flags
 	if len(os.Args) > 1 {
 		arg := os.Args[1]
+		if cmd := findCommand(arg); cmd != nil {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
 		if arg == "-v" || arg == "--version" || arg == "version" {
 			fmt.Println("portsweep", version)
 			return
@@ -24,16 +191,52 @@ func main() {
 			printHelp()
 			return
 		}
-		// Treat as filter argument (port number or process name)
-		initialFilter = arg
 	}
 
-	p := tea.NewProgram(NewModel(initialFilter), tea.WithAltScreen())
+	// Remaining arguments are flags and a filter argument (port number or process name)
+	opts, err := parseRootArgs(os.Args[1:])
+	if err != nil {
+		eprintf("%v (see portsweep --help)", err)
+		os.Exit(2)
+	}
+
+	// Pipes and editor tasks get a plain table instead of the TUI
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		os.Exit(runPlain(opts.filter))
+	}
 
-	if _, err := p.Run(); err != nil {
+	// Columns given on the command line are used for this session only
+	columns := opts.columns
+	if columns == nil {
+		columns = loadColumns()
+	}
+	modelOpts := Options{
+		InitialFilter: opts.filter,
+		DryRun:        opts.dryRun,
+		KillDelay:     opts.killDelay,
+		Sort:          loadSortOrder(),
+		SearchHistory: loadSearchHistory(),
+		Columns:       columns,
+	}
+	var programOpts []tea.ProgramOption
+	if opts.inline {
+		modelOpts.MaxRows = opts.height
+	} else {
+		programOpts = append(programOpts, tea.WithAltScreen())
+	}
+
+	p := tea.NewProgram(NewModel(modelOpts), programOpts...)
+
+	final, err := p.Run()
+	if err != nil {
 		fmt.Printf("Error running portsweep: %v\n", err)
 		os.Exit(1)
 	}
+
+	// Leave a record of what was signalled in the scrollback
+	if m, ok := final.(Model); ok {
+		printKillSummary(os.Stdout, m.killLog)
+	}
 }
 
 func printHelp() {
@@ -43,23 +246,96 @@ Usage:
   portsweep [flags]
   portsweep <port>      Kill process on specific port (e.g., portsweep 3000)
   portsweep <name>      Kill processes matching name (e.g., portsweep node)
+  portsweep <query>     Kill processes matching a query (e.g., portsweep 'port:3000-3999 -user:root')
+  portsweep <command> [flags] [-- args...]
+
+Commands:
+  exec          Clear a port, then run a command
+                (e.g., portsweep exec --port 3000 -- npm run dev)
+  restart       Kill the process on a port and start it again, detached, with the
+                same command, working directory and environment
+                (e.g., portsweep restart 3000)
+  run           Run a command on an automatically chosen free port
+                (e.g., portsweep run --env PORT --range 4000-4999 -- npm run dev)
+  guard         Run a command and offer to kill whatever holds its port
+                when it fails with "address already in use"
+                (e.g., portsweep guard -- npm run dev)
+  leak-check    Run a command and report listeners it left running
+                (e.g., portsweep leak-check --kill -- go test ./...)
+  snapshot      Save the current listeners or compare two snapshots
+                (e.g., portsweep snapshot save morning.json,
+                       portsweep snapshot diff morning.json live)
+  watch         Print an event whenever a listener opens, closes or changes owner
+                (e.g., portsweep watch --json)
+  plan          Write a JSON plan of the processes matching the filters
+                (e.g., portsweep plan -o plan.json --signal TERM 3000 node)
+  apply         Re-verify and signal the targets of a plan
+                (e.g., portsweep apply plan.json, portsweep apply --dry-run plan.json)
+  history       Show kills recorded in the audit log
+                (e.g., portsweep history --since 1h --port 3000)
+  completion    Print a completion script for bash, zsh or fish
+                (e.g., source <(portsweep completion bash))
 
 Arguments:
   <port>        Port number to match (exact match)
   <name>        Process name or command to match (case-insensitive)
+  <query>       Terms such as port:3000-3999, user:root, name:node, cmd:/vite|next/
+                or addr:127.0.0.1; all terms must match unless joined with OR,
+                - negates a term and parentheses group terms; a filter
+                starting with - is not taken for a flag, and everything
+                after -- is a filter
+
+After quitting, portsweep prints a summary of every process it signalled.
+Every kill is also appended to $XDG_STATE_HOME/portsweep/history.jsonl
+(~/.local/state/portsweep/history.jsonl by default).
+
+Processes are shut down with a strategy picked per application: SIGINT for
+postgres, SIGQUIT for nginx and unicorn, docker stop for published container
+ports and SIGTERM otherwise. Add your own rules in
+$XDG_CONFIG_HOME/portsweep/strategies.json (~/.config/portsweep/strategies.json).
+
+When stdin or stdout is not a terminal, portsweep prints the matching
+processes as a plain-text table instead of starting the TUI, and exits
+with status 1 if nothing matched.
 
 Flags:
   -h, --help      Show this help message
   -v, --version   Show version
+  --inline        Draw in the normal screen instead of the alternate screen
+  --height <n>    Maximum number of process rows in inline mode (default 15)
+  --dry-run       Go through the kill flow without sending any signal
+  --kill-delay <d>
+                  Wait this long after confirming before killing (e.g., 5s);
+                  press u or esc to cancel during the countdown
+  --columns <list>
+                  Columns to show, in order (e.g., port,pid,process,uptime,mem,command);
+                  one of port, pid, process, user, command, addr, proto, ppid,
+                  uptime, cpu, mem, cwd, project, conns
 
 Keybindings:
   ↑/k          Move up
   ↓/j          Move down
+  pgup/ctrl+u  Page up
+  pgdn/ctrl+d  Page down
+  home/g       Jump to the first process
+  end/G        Jump to the last process
   space/tab    Select/deselect process
   a            Select all
   enter/d      Kill selected process(es)
+  o            Sort by the next column (port, PID, process, user, command, uptime, memory)
+  O            Reverse the sort order
+  c            Choose columns (space shows/hides, K/J reorder)
+  i            Toggle the detail pane of the focused process (secrets masked)
+  [/]          Scroll the detail pane
+  v            Toggle one row per listener (port, address, protocol)
   r            Refresh
   s            Toggle system ports (<1024)
+  D            Toggle dry run (record kills instead of sending them)
+  u/esc        Cancel pending kills (with --kill-delay)
+  R            Restart the focused process with its original command and directory
+  l            Show recently killed processes (enter relaunches one)
   /            Search/filter processes
+  ctrl+t       Toggle fuzzy search (ranked, with matches highlighted)
+  ↑/↓          Browse earlier searches (while searching)
   q            Quit`)
 }
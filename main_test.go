@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRootArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    rootOptions
+		wantErr bool
+	}{
+		{"no arguments", nil, rootOptions{height: DefaultInlineHeight}, false},
+		{"filter only", []string{"3000"}, rootOptions{filter: "3000", height: DefaultInlineHeight}, false},
+		{"flag before filter", []string{"--inline", "node"}, rootOptions{filter: "node", inline: true, height: DefaultInlineHeight}, false},
+		{"flags after filter", []string{"node", "--inline", "--height", "5"}, rootOptions{filter: "node", inline: true, height: 5}, false},
+		{"two filters", []string{"node", "vite"}, rootOptions{}, true},
+		{"unknown flag", []string{"--bogus"}, rootOptions{}, true},
+		{"invalid height", []string{"--height", "0"}, rootOptions{}, true},
+		{"kill delay", []string{"--kill-delay", "5s"}, rootOptions{height: DefaultInlineHeight, killDelay: 5 * time.Second}, false},
+		{"negative kill delay", []string{"--kill-delay", "-1s"}, rootOptions{}, true},
+		{"columns", []string{"--columns", "port,uptime,cwd"}, rootOptions{height: DefaultInlineHeight, columns: []columnID{colPort, colUptime, colCwd}}, false},
+		{"unknown column", []string{"--columns", "port,size"}, rootOptions{}, true},
+		{"negated filter", []string{"-user:root port:3000"}, rootOptions{filter: "-user:root port:3000", height: DefaultInlineHeight}, false},
+		{"negated filter after flag", []string{"--inline", "-name:node"}, rootOptions{filter: "-name:node", inline: true, height: DefaultInlineHeight}, false},
+		{"filter after --", []string{"--dry-run", "--", "-port:22"}, rootOptions{filter: "-port:22", dryRun: true, height: DefaultInlineHeight}, false},
+		{"negative flag value", []string{"node", "--kill-delay", "-1s"}, rootOptions{}, true},
+		{"single dash flag", []string{"-inline"}, rootOptions{inline: true, height: DefaultInlineHeight}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRootArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRootArgs(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRootArgs(%v) = %+v, expected %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintKillSummary(t *testing.T) {
+	var out strings.Builder
+	printKillSummary(&out, nil)
+	if out.String() != "" {
+		t.Errorf("expected no summary without kills, got %q", out.String())
+	}
+
+	printKillSummary(&out, []killRecord{
+		{PID: 123, Port: 3000, Command: "vite (web)"},
+		{PID: 1, Port: 22, Command: "sshd", Err: errors.New("operation not permitted")},
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 lines, got:\n%s", out.String())
+	}
+	if lines[0] != "portsweep: signalled 1 process, 1 failed" {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "3000") || !strings.Contains(lines[1], "vite (web)") || !strings.HasSuffix(lines[1], "sent SIGTERM") {
+		t.Errorf("unexpected line %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "failed: operation not permitted") {
+		t.Errorf("unexpected line %q", lines[2])
+	}
+}
+
+func TestPrintKillSummaryDryRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		records    []killRecord
+		wantHeader string
+	}{
+		{
+			name:       "dry run only",
+			records:    []killRecord{{PID: 123, Port: 3000, DryRun: true}},
+			wantHeader: "portsweep: dry run, would have signalled 1 process",
+		},
+		{
+			name: "mixed",
+			records: []killRecord{
+				{PID: 123, Port: 3000, DryRun: true},
+				{PID: 456, Port: 8080},
+			},
+			wantHeader: "portsweep: signalled 1 process, 1 more in dry run",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			printKillSummary(&out, tt.records)
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if lines[0] != tt.wantHeader {
+				t.Errorf("header = %q, expected %q", lines[0], tt.wantHeader)
+			}
+			if !strings.HasSuffix(lines[1], "would send SIGTERM (dry run)") {
+				t.Errorf("unexpected line %q", lines[1])
+			}
+		})
+	}
+}
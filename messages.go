@@ -7,9 +7,17 @@ import "time"
 // tickMsg is sent periodically to trigger auto-refresh
 type tickMsg time.Time
 
+// countdownMsg updates the countdown of pending kills. id identifies the
+// pending batch, so ticks of a cancelled batch are ignored.
+type countdownMsg struct {
+	id int
+}
+
 // refreshMsg contains the updated process list or an error
 type refreshMsg struct {
 	processes []Process
+	table     map[int]procInfo      // process table at the time of the scan; nil if it could not be read
+	extras    map[int]processExtras // details for the shown columns; nil if none need them
 	err       error
 }
 
@@ -18,5 +26,24 @@ type killResultMsg struct {
 	success   bool
 	pid       int
 	port      int
-	remaining int // how many left to kill
+	remaining int         // how many left to kill
+	err       error       // why the kill failed, if it did
+	dryRun    bool        // whether the kill was only recorded
+	spec      *launchSpec // how to start the process again; nil if it could not be captured
+	auditErr  error       // why the kill could not be recorded in the audit log, if it could not
+}
+
+// restartResultMsg reports the result of restarting or relaunching a process
+type restartResultMsg struct {
+	label    string
+	pid      int         // PID of the new process
+	logPath  string      // where the new process writes its output
+	killed   *killRecord // the kill that preceded the relaunch; nil when relaunching from the list
+	err      error
+	auditErr error // why the kill could not be recorded in the audit log, if it could not
+}
+
+// detailMsg contains the details of a process for the detail pane
+type detailMsg struct {
+	detail processDetail
 }
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
-	"sort"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // Configuration constants
@@ -22,27 +26,49 @@ const (
 	// SystemPortThreshold is the boundary between system and user ports
 	SystemPortThreshold = 1024
 
-	// DefaultCommandWidth is the minimum width for the command column
+	// DefaultCommandWidth is the widest the command and cwd columns get when terminal width is unknown
 	DefaultCommandWidth = 50
 
-	// MinTerminalWidth is the threshold for adjusting command width
-	MinTerminalWidth = 60
-
-	// ColumnWidthOffset accounts for other columns when calculating command width
-	ColumnWidthOffset = 55
-
 	// MinFullCommandWidth is the minimum width for the full command detail line
 	MinFullCommandWidth = 20
 
 	// DefaultFullCommandWidth is used when terminal width is unknown
 	DefaultFullCommandWidth = 80
+
+	// listHeaderLines is the number of lines above the process rows (title, margin and column header)
+	listHeaderLines = 3
+
+	// CountdownInterval is how often the pending kill countdown updates
+	CountdownInterval = time.Second
+
+	// NewRowHighlight is how long rows of newly appeared processes stay highlighted
+	NewRowHighlight = 3 * time.Second
+
+	// GhostDuration is how long a process that stopped listening stays listed as exited
+	GhostDuration = 5 * time.Second
+
+	// DetailPaneMinWidth is the terminal width from which the detail pane goes beside the list instead of below it
+	DetailPaneMinWidth = 120
+
+	// DetailPaneMinColumns is the narrowest the detail pane gets beside the list
+	DetailPaneMinColumns = 44
+
+	// DetailPaneMinLines is the lowest the detail pane gets below the list
+	DetailPaneMinLines = 6
+
+	// DetailPaneMaxLines is the height of the detail pane when terminal height is unknown
+	DetailPaneMaxLines = 20
+
+	// DetailRereadInterval is how often the detail pane reads the focused process
+	// again; CPU and memory are updated on every refresh
+	DetailRereadInterval = 10 * time.Second
 )
 
 // Model represents the TUI state
 type Model struct {
 	processes       []Process
 	cursor          int
-	selected        map[int]bool // PID -> selected
+	selected        map[rowKey]bool // rows selected
 	showSystemPorts bool
 	confirming      bool
 	toKill          []Process // processes to kill in batch
@@ -51,27 +77,121 @@ type Model struct {
 	statusTime      time.Time
 	width           int
 	height          int
-	initialFilter   string // filter from CLI argument (port or name)
-	filterApplied   bool   // whether we've applied the initial filter
-	searching       bool   // whether in search mode
-	searchQuery     string // current search query
-	lastError       error  // last error from port scanning
+	initialFilter   string           // filter from CLI argument (port or name)
+	filterApplied   bool             // whether we've applied the initial filter
+	searching       bool             // whether in search mode
+	searchQuery     string           // current search query
+	filter          *query           // last valid parse of the search query
+	searchErr       error            // why the search query does not parse, if it does not
+	fuzzy           bool             // whether search matches fuzzily instead of as a query
+	searchInput     textinput.Model  // the search bar
+	searchHistory   []string         // past searches, oldest first
+	historyIndex    int              // search history entry shown while browsing; len(searchHistory) for none
+	searchDraft     string           // what was typed before browsing the search history
+	lastError       error            // last error from port scanning
+	maxRows         int              // maximum number of process rows to render, 0 for no limit
+	killLog         []killRecord     // every kill attempted this session, for the exit summary
+	auditErr        error            // last failure to record a kill of the current batch in the audit log
+	killer          ProcessKiller    // sends kills and records them in the audit log
+	dryRun          bool             // whether kills are recorded instead of sent
+	recorder        *RecordingKiller // receives kills while in dry run
+	killDelay       time.Duration    // how long confirmed kills wait before being sent
+	pending         []Process        // confirmed kills waiting for the delay to pass
+	killAt          time.Time        // when the pending kills are sent
+	pendingID       int              // identifies the pending batch for countdownMsg
+	pendingRestart  bool             // whether the pending kill is a restart
+	restarting      bool             // whether the confirmation is for a restart rather than a kill
+	restartSplit    bool             // whether the process to restart only has its argv split from its command line
+	recent          []launchSpec     // recently killed processes, newest first, for relaunching
+	showRecent      bool             // whether the recently killed list is open
+	recentCursor    int              // cursor in the recently killed list
+	offset          int              // index of the first visible row
+	sort            sortOrder        // how the process list is sorted
+	procTable       map[int]procInfo // start times and memory of the listed processes, for sorting
+
+	// Changes between scans, for highlighting
+	scanned  bool              // whether the first scan has completed
+	appeared map[int]time.Time // PID -> when the process started listening
+	ghosts   []ghost           // processes that recently stopped listening
+
+	// Columns of the process list
+	columns        []columnID            // shown columns, in order
+	extras         map[int]processExtras // details only gathered while a column shows them
+	pickingColumns bool                  // whether the column picker is open
+	columnPicker   []columnChoice        // every column, shown ones first, while the picker is open
+	pickerCursor   int                   // cursor in the column picker
+
+	// Detail pane of the focused process
+	showDetail   bool          // whether the detail pane is shown
+	detail       processDetail // details of the last process read
+	detailFor    int           // PID whose details were last requested, 0 to read them again
+	detailRead   time.Time     // when the details were last requested
+	detailOffset int           // first line of the detail pane shown
+
+	// One row per listening socket instead of one per process
+	perListener bool
+
+	// Filtered process list
+	list        *listCache // the last filtered list, shared by copies of the model
+	listVersion int        // bumped whenever the process list or table changes
+}
+
+// ghost is a process that stopped listening, shown greyed out for a while
+type ghost struct {
+	Process
+	GoneAt time.Time
 }
 
-// NewModel creates a new Model with optional initial filter
-func NewModel(initialFilter string) Model {
+// Options configures a new Model
+type Options struct {
+	InitialFilter string        // filter from CLI argument (port or name)
+	MaxRows       int           // maximum number of process rows to render, 0 for no limit
+	DryRun        bool          // start with kills recorded instead of sent
+	KillDelay     time.Duration // delay between confirming and sending kills, 0 to send at once
+	Sort          sortOrder     // initial sort order, zero for lowest port first
+	SearchHistory []string      // past searches, oldest first
+	Columns       []columnID    // columns to show, in order; nil for the defaults
+}
+
+// NewModel creates a new Model from the given options
+func NewModel(opts Options) Model {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = defaultColumns
+	}
 	return Model{
 		processes:       []Process{},
 		cursor:          0,
-		selected:        make(map[int]bool),
+		selected:        make(map[rowKey]bool),
+		appeared:        make(map[int]time.Time),
 		showSystemPorts: false,
 		confirming:      false,
 		toKill:          []Process{},
-		initialFilter:   initialFilter,
+		initialFilter:   opts.InitialFilter,
 		filterApplied:   false,
+		maxRows:         opts.MaxRows,
+		dryRun:          opts.DryRun,
+		killer:          newKiller("tui"),
+		recorder:        &RecordingKiller{},
+		killDelay:       opts.KillDelay,
+		sort:            cmp.Or(opts.Sort, defaultSortOrder),
+		searchInput:     newSearchInput(),
+		searchHistory:   opts.SearchHistory,
+		columns:         columns,
+		list:            &listCache{},
 	}
 }
 
+// newSearchInput returns the line editor used for the search bar
+func newSearchInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "/"
+	input.PromptStyle = searchStyle
+	input.TextStyle = searchStyle
+	input.Cursor.SetMode(cursor.CursorStatic)
+	return input
+}
+
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
@@ -89,123 +209,346 @@ func (m Model) tickCmd() tea.Cmd {
 
 // refreshPorts fetches the current listening ports
 func (m Model) refreshPorts() tea.Cmd {
+	columns, known := m.columns, m.extras
 	return func() tea.Msg {
 		processes, err := GetListeningPorts()
-		return refreshMsg{processes: processes, err: err}
+		// The process table is only needed for sorting and extra columns, so a failure is not an error
+		table, _ := readProcessTable()
+		extras := readProcessExtras(processes, columns, known)
+		return refreshMsg{processes: processes, table: table, extras: extras, err: err}
 	}
 }
 
-// killProcess kills the specified process
-func (m Model) killProcess(pid int, port int, remaining int) tea.Cmd {
+// killProcess kills the specified process, or only records it in dry run
+func (m Model) killProcess(p Process, remaining int) tea.Cmd {
 	return func() tea.Msg {
-		err := KillProcess(pid)
-		return killResultMsg{
-			success:   err == nil,
-			pid:       pid,
-			port:      port,
-			remaining: remaining,
+		msg := killResultMsg{pid: p.PID, port: p.LowestPort(), remaining: remaining, dryRun: m.dryRun}
+		if m.dryRun {
+			msg.err = m.recorder.Kill(p.PID)
+		} else {
+			// Remember how to start the process again before it is gone
+			if spec, err := captureLaunchSpec(p); err == nil {
+				msg.spec = &spec
+			}
+			msg.err, msg.auditErr = killAndAudit(m.killer, killTarget{Process: p, StartTime: m.procTable[p.PID].StartTime})
 		}
+		msg.success = killSent(msg.err)
+		return msg
 	}
 }
 
-// filteredProcesses returns processes filtered by system port setting and search query
-func (m Model) filteredProcesses() []Process {
-	filtered := make([]Process, 0)
+// restartProcess kills the specified process and starts it again once its ports are free
+func (m Model) restartProcess(p Process) tea.Cmd {
+	return func() tea.Msg {
+		msg := restartResultMsg{label: processLabel(p)}
+		// The kill is recorded for the exit summary, with any audit log failure.
+		// A failed shutdown action only shows there, as the process was still signalled.
+		kill := killerFunc(func(int) error {
+			record := killRecord{PID: p.PID, Port: p.LowestPort(), Command: processLabel(p), Strategy: killDescription(m.killer, p)}
+			record.Err, msg.auditErr = killAndAudit(m.killer, killTarget{Process: p, StartTime: m.procTable[p.PID].StartTime})
+			msg.killed = &record
+			if killSent(record.Err) {
+				return nil
+			}
+			return record.Err
+		})
+		msg.pid, msg.logPath, msg.err = restartProcess(defaultScanner, kill, p, DefaultReleaseTimeout)
+		return msg
+	}
+}
+
+// relaunchProcess starts a recently killed process again
+func (m Model) relaunchProcess(spec launchSpec) tea.Cmd {
+	return func() tea.Msg {
+		pid, logPath, err := relaunch(spec)
+		return restartResultMsg{label: spec.Label, pid: pid, logPath: logPath, err: err}
+	}
+}
+
+// countdownCmd returns a command that updates the countdown of the pending kills
+func (m Model) countdownCmd() tea.Cmd {
+	id := m.pendingID
+	wait := min(CountdownInterval, time.Until(m.killAt))
+	return tea.Tick(wait, func(time.Time) tea.Msg {
+		return countdownMsg{id: id}
+	})
+}
 
+// startKill begins killing a batch of processes one after another
+func (m *Model) startKill(procs []Process) tea.Cmd {
+	m.toKill = procs
+	m.killIndex = 0
+	return m.killProcess(m.toKill[0], len(m.toKill)-1)
+}
+
+// stillListening returns the processes that are still in the latest scan
+func (m Model) stillListening(procs []Process) []Process {
+	existing := make(map[int]bool)
 	for _, p := range m.processes {
-		// First, apply system port filter
-		if !m.showSystemPorts {
-			hasUserPort := false
-			for _, port := range p.Ports {
-				if port >= SystemPortThreshold {
-					hasUserPort = true
-					break
-				}
-			}
-			if !hasUserPort {
-				continue
-			}
+		existing[p.PID] = true
+	}
+	var result []Process
+	for _, p := range procs {
+		if existing[p.PID] {
+			result = append(result, p)
 		}
+	}
+	return result
+}
 
-		// Then, apply search filter if query is set
-		if m.searchQuery != "" {
-			query := strings.ToLower(m.searchQuery)
-			matchesName := strings.Contains(strings.ToLower(p.Name), query)
-			matchesCommand := strings.Contains(strings.ToLower(p.Command), query)
-			matchesPort := false
-			for _, port := range p.Ports {
-				if strings.Contains(strconv.Itoa(port), m.searchQuery) {
-					matchesPort = true
-					break
-				}
-			}
-			if !matchesName && !matchesCommand && !matchesPort {
-				continue
-			}
+// rows returns the rows of the process list before filtering: the processes,
+// or their listeners in the listener view
+func (m Model) rows() []Process {
+	if !m.perListener {
+		return m.processes
+	}
+	rows := listenerRows(m.processes)
+	sortProcesses(rows, m.sort, m.procTable)
+	return rows
+}
+
+// rowKey identifies a row of the process list in the current view
+func (m Model) rowKey(p Process) rowKey {
+	if m.perListener {
+		return listenerKey(p)
+	}
+	return processKey(p)
+}
+
+// listKey is everything the filtered process list depends on
+type listKey struct {
+	version         int
+	sort            sortOrder
+	perListener     bool
+	showSystemPorts bool
+	fuzzy           bool
+	searchQuery     string
+	filter          *query
+}
+
+// listCache holds the filtered process list, so that it is ranked once per
+// change rather than by every caller of filteredProcesses. It is shared by
+// copies of the model.
+type listCache struct {
+	valid     bool
+	key       listKey
+	processes []Process
+	positions map[rowKey]map[fuzzyField][]int // where the fuzzy search matched each row
+	widths    []int                           // natural column widths over every row, nil until rendered
+	widthsFor []columnID                      // columns the widths were measured for
+}
+
+// listKey returns the key the filtered process list is cached under
+func (m Model) listKey() listKey {
+	return listKey{
+		version:         m.listVersion,
+		sort:            m.sort,
+		perListener:     m.perListener,
+		showSystemPorts: m.showSystemPorts,
+		fuzzy:           m.fuzzy,
+		searchQuery:     m.searchQuery,
+		filter:          m.filter,
+	}
+}
+
+// filteredProcesses returns processes filtered by system port setting and search query
+func (m Model) filteredProcesses() []Process {
+	return m.filteredList().processes
+}
+
+// filteredList returns the filtered process list, from the cache if nothing
+// it depends on has changed
+func (m Model) filteredList() *listCache {
+	key := m.listKey()
+	if m.list != nil && m.list.valid && m.list.key == key {
+		return m.list
+	}
+
+	list := &listCache{valid: true, key: key, processes: make([]Process, 0)}
+	for _, p := range m.rows() {
+		if !m.showSystemPorts && !hasUserPort(p) {
+			continue
+		}
+		// Fuzzy matches are filtered and ranked below
+		if m.fuzzy || m.filter.matches(p) {
+			list.processes = append(list.processes, p)
 		}
+	}
+	if m.fuzzy && m.searchQuery != "" {
+		var results []fuzzyResult
+		list.processes, results = rankFuzzy(m.searchQuery, list.processes)
+		list.positions = make(map[rowKey]map[fuzzyField][]int, len(results))
+		for i, r := range results {
+			list.positions[m.rowKey(list.processes[i])] = r.Positions
+		}
+	}
 
-		filtered = append(filtered, p)
+	if m.list != nil {
+		*m.list = *list
 	}
+	return list
+}
 
-	return filtered
+// shows reports whether a process passes the system port filter and the search query
+func (m Model) shows(p Process) bool {
+	if !m.showSystemPorts && !hasUserPort(p) {
+		return false
+	}
+	if m.fuzzy {
+		_, ok := fuzzyMatchProcess(m.searchQuery, p)
+		return ok
+	}
+	return m.filter.matches(p)
 }
 
-// selectedCount returns the number of selected processes
+// fuzzyPositions returns where the fuzzy search matched a process, or nil
+// when not searching fuzzily
+func (m Model) fuzzyPositions(p Process) map[fuzzyField][]int {
+	if !m.fuzzy || m.searchQuery == "" {
+		return nil
+	}
+	if positions, ok := m.filteredList().positions[m.rowKey(p)]; ok {
+		return positions
+	}
+	r, _ := fuzzyMatchProcess(m.searchQuery, p)
+	return r.Positions
+}
+
+// updateSearchInput passes a message to the search bar and applies the search if it changed
+func (m *Model) updateSearchInput(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	if text := m.searchInput.Value(); text != m.searchQuery {
+		m.setSearch(text)
+		// Reset cursor to 0 when search changes
+		m.cursor = 0
+	}
+	return cmd
+}
+
+// clearSearch empties the search bar and removes the filter
+func (m *Model) clearSearch() {
+	m.searchInput.SetValue("")
+	m.setSearch("")
+}
+
+// browseHistory replaces the search with an older (delta -1) or newer
+// (delta 1) entry of the search history. Going past the newest entry brings
+// back what was being typed.
+func (m *Model) browseHistory(delta int) {
+	i := m.historyIndex + delta
+	if i < 0 || i > len(m.searchHistory) {
+		return
+	}
+	if m.historyIndex == len(m.searchHistory) {
+		m.searchDraft = m.searchInput.Value()
+	}
+	m.historyIndex = i
+	text := m.searchDraft
+	if i < len(m.searchHistory) {
+		text = m.searchHistory[i]
+	}
+	m.searchInput.SetValue(text)
+	m.searchInput.CursorEnd()
+	m.setSearch(text)
+	m.cursor = 0
+}
+
+// toggleFuzzy switches search between fuzzy matching and the query language
+func (m *Model) toggleFuzzy() {
+	m.keepFocus(func() {
+		m.fuzzy = !m.fuzzy
+	})
+	if m.fuzzy {
+		m.statusMessage = "Fuzzy search"
+	} else {
+		m.statusMessage = "Exact search"
+	}
+	m.statusTime = time.Now()
+}
+
+// setSearch updates the search query. A query that does not parse leaves the
+// last valid filter in place, so the list does not jump around while typing.
+func (m *Model) setSearch(text string) {
+	m.searchQuery = text
+	q, err := parseQuery(text)
+	m.searchErr = err
+	if err == nil {
+		m.filter = q
+	}
+}
+
+// selectedCount returns the number of selected rows
 func (m Model) selectedCount() int {
 	count := 0
 	filtered := m.filteredProcesses()
 	for _, p := range filtered {
-		if m.selected[p.PID] {
+		if m.selected[m.rowKey(p)] {
 			count++
 		}
 	}
 	return count
 }
 
-// getSelectedProcesses returns all selected processes
+// getSelectedProcesses returns the processes of all selected rows, each once
 func (m Model) getSelectedProcesses() []Process {
 	var result []Process
 	filtered := m.filteredProcesses()
 	for _, p := range filtered {
-		if m.selected[p.PID] {
+		if m.selected[m.rowKey(p)] {
 			result = append(result, p)
 		}
 	}
-	return result
+	return uniqueProcesses(result, m.processes)
 }
 
 // applyInitialFilter pre-selects processes matching the CLI filter argument
 func (m *Model) applyInitialFilter() {
-	if m.initialFilter == "" {
+	q, err := parseQuery(m.initialFilter)
+	if q == nil || err != nil {
 		return
 	}
 
-	// Check if filter is a port number (exact match)
-	if port, err := strconv.Atoi(m.initialFilter); err == nil {
-		// It's a port number - exact match
-		for _, p := range m.processes {
-			for _, pPort := range p.Ports {
-				if pPort == port {
-					m.selected[p.PID] = true
-					break
-				}
-			}
-		}
-	} else {
-		// It's a name/command filter - case-insensitive substring match
-		filterLower := strings.ToLower(m.initialFilter)
-		for _, p := range m.processes {
-			nameLower := strings.ToLower(p.Name)
-			cmdLower := strings.ToLower(p.Command)
-			if strings.Contains(nameLower, filterLower) || strings.Contains(cmdLower, filterLower) {
-				m.selected[p.PID] = true
-			}
+	for _, p := range m.rows() {
+		if q.matches(p) {
+			m.selected[m.rowKey(p)] = true
 		}
 	}
 }
 
-// Update handles messages
+// Update handles messages and then scrolls the list to keep the cursor visible
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := m.update(msg)
+	m.offset, _ = m.visibleRows(m.filteredProcesses())
+	if read := m.requestDetail(); read != nil {
+		cmd = tea.Batch(cmd, read)
+	}
+	return m, cmd
+}
+
+// requestDetail returns a command reading the details of the focused process,
+// if the detail pane is shown and they were not requested yet
+func (m *Model) requestDetail() tea.Cmd {
+	if !m.showDetail {
+		return nil
+	}
+	p, ok := m.focused()
+	if !ok || p.PID == m.detailFor {
+		return nil
+	}
+	if p.PID != m.detail.PID {
+		m.detailOffset = 0
+	}
+	m.detailFor = p.PID
+	m.detailRead = time.Now()
+	table := m.procTable
+	return func() tea.Msg {
+		return detailMsg{detail: readProcessDetail(p.PID, table)}
+	}
+}
+
+// update handles messages
+func (m Model) update(msg tea.Msg) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		// Handle confirmation mode
@@ -213,15 +556,49 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch {
 			case key.Matches(msg, keys.Confirm):
 				m.confirming = false
-				if len(m.toKill) > 0 {
-					// Start batch kill
-					m.killIndex = 0
+				if len(m.toKill) == 0 {
+					return m, nil
+				}
+				if m.restarting {
+					m.restarting = false
 					p := m.toKill[0]
-					return m, m.killProcess(p.PID, p.LowestPort(), len(m.toKill)-1)
+					m.toKill = nil
+					if m.dryRun {
+						m.killLog = append(m.killLog, killRecord{
+							PID:      p.PID,
+							Port:     p.LowestPort(),
+							Command:  processLabel(p),
+							DryRun:   true,
+							Strategy: killDescription(m.killer, p) + " and restart",
+						})
+						m.statusMessage = fmt.Sprintf("Would restart process %d (dry run)", p.PID)
+						m.statusTime = time.Now()
+						return m, nil
+					}
+					if m.killDelay > 0 {
+						m.pending = []Process{p}
+						m.pendingRestart = true
+						m.killAt = time.Now().Add(m.killDelay)
+						m.pendingID++
+						return m, m.countdownCmd()
+					}
+					m.statusMessage = fmt.Sprintf("Restarting %s...", processLabel(p))
+					m.statusTime = time.Now()
+					return m, m.restartProcess(p)
 				}
-				return m, nil
+				if m.killDelay > 0 {
+					// Give the user a chance to undo before anything is sent
+					m.pending = m.toKill
+					m.pendingRestart = false
+					m.toKill = nil
+					m.killAt = time.Now().Add(m.killDelay)
+					m.pendingID++
+					return m, m.countdownCmd()
+				}
+				return m, m.startKill(m.toKill)
 			case key.Matches(msg, keys.Cancel):
 				m.confirming = false
+				m.restarting = false
 				m.toKill = nil
 				m.statusMessage = "Cancelled"
 				m.statusTime = time.Now()
@@ -230,40 +607,116 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// Recently killed list key handling
+		if m.showRecent {
+			switch {
+			case key.Matches(msg, keys.Quit):
+				return m, tea.Quit
+			case key.Matches(msg, keys.Recent), msg.Type == tea.KeyEsc:
+				m.showRecent = false
+			case key.Matches(msg, keys.Up):
+				if m.recentCursor > 0 {
+					m.recentCursor--
+				}
+			case key.Matches(msg, keys.Down):
+				if m.recentCursor < len(m.recent)-1 {
+					m.recentCursor++
+				}
+			case key.Matches(msg, keys.Kill):
+				spec := m.recent[m.recentCursor]
+				m.showRecent = false
+				if m.dryRun {
+					m.statusMessage = fmt.Sprintf("Would relaunch %s (dry run)", spec.Label)
+					m.statusTime = time.Now()
+					return m, nil
+				}
+				// It is running again, so it no longer belongs in the list
+				m.recent = append(m.recent[:m.recentCursor:m.recentCursor], m.recent[m.recentCursor+1:]...)
+				m.statusMessage = fmt.Sprintf("Relaunching %s...", spec.Label)
+				m.statusTime = time.Now()
+				return m, m.relaunchProcess(spec)
+			}
+			return m, nil
+		}
+
+		// Column picker key handling
+		if m.pickingColumns {
+			switch {
+			case key.Matches(msg, keys.Quit):
+				return m, tea.Quit
+			case key.Matches(msg, keys.Columns), msg.Type == tea.KeyEsc, msg.Type == tea.KeyEnter:
+				m.pickingColumns = false
+				columns := m.columns
+				return m, func() tea.Msg {
+					saveColumns(columns)
+					return nil
+				}
+			case key.Matches(msg, keys.MoveUp):
+				m.moveColumn(-1)
+			case key.Matches(msg, keys.MoveDown):
+				m.moveColumn(1)
+			case key.Matches(msg, keys.Up):
+				if m.pickerCursor > 0 {
+					m.pickerCursor--
+				}
+			case key.Matches(msg, keys.Down):
+				if m.pickerCursor < len(m.columnPicker)-1 {
+					m.pickerCursor++
+				}
+			case key.Matches(msg, keys.Select):
+				return m, m.toggleColumn()
+			}
+			return m, nil
+		}
+
 		// Search mode key handling
 		if m.searching {
-			switch msg.Type {
-			case tea.KeyEsc:
+			switch {
+			case key.Matches(msg, keys.Fuzzy):
+				m.toggleFuzzy()
+				return m, nil
+			case msg.Type == tea.KeyEsc:
 				// Clear search and exit search mode
 				m.searching = false
-				m.searchQuery = ""
+				m.searchInput.Blur()
+				m.clearSearch()
 				// Reset cursor if out of bounds
 				filtered := m.filteredProcesses()
 				if m.cursor >= len(filtered) {
 					m.cursor = max(0, len(filtered)-1)
 				}
 				return m, nil
-			case tea.KeyBackspace:
-				if len(m.searchQuery) > 0 {
-					m.searchQuery = m.searchQuery[:len(m.searchQuery)-1]
-					// Reset cursor if out of bounds after filter change
-					filtered := m.filteredProcesses()
-					if m.cursor >= len(filtered) {
-						m.cursor = max(0, len(filtered)-1)
-					}
+			case msg.Type == tea.KeyEnter:
+				// Exit search mode but keep the filter, unless it does not parse
+				if m.searchErr != nil && !m.fuzzy {
+					return m, nil
 				}
-				return m, nil
-			case tea.KeyEnter:
-				// Exit search mode but keep the filter
 				m.searching = false
+				m.searchInput.Blur()
+				m.searchHistory = addSearchHistory(m.searchHistory, m.searchQuery)
+				history := m.searchHistory
+				return m, func() tea.Msg {
+					saveSearchHistory(history)
+					return nil
+				}
+			case msg.Type == tea.KeyUp:
+				m.browseHistory(-1)
 				return m, nil
-			case tea.KeyRunes:
-				// Append typed characters to search query
-				m.searchQuery += string(msg.Runes)
-				// Reset cursor to 0 when search changes
-				m.cursor = 0
+			case msg.Type == tea.KeyDown:
+				m.browseHistory(1)
 				return m, nil
 			}
+			return m, m.updateSearchInput(msg)
+		}
+
+		// Pending kills can be cancelled until they are sent
+		if len(m.pending) > 0 && key.Matches(msg, keys.Undo) {
+			m.statusMessage = fmt.Sprintf("Cancelled killing %s", pluralize(len(m.pending), "process", "processes"))
+			if m.pendingRestart {
+				m.statusMessage = fmt.Sprintf("Cancelled restarting %s", processLabel(m.pending[0]))
+			}
+			m.statusTime = time.Now()
+			m.pending = nil
 			return m, nil
 		}
 
@@ -274,12 +727,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case key.Matches(msg, keys.Search):
 			m.searching = true
-			return m, nil
+			m.searchInput.SetValue(m.searchQuery)
+			m.searchInput.CursorEnd()
+			m.historyIndex = len(m.searchHistory)
+			return m, m.searchInput.Focus()
+
+		case key.Matches(msg, keys.Fuzzy):
+			m.toggleFuzzy()
 
 		case key.Matches(msg, keys.Cancel):
 			// Clear search filter if active (Esc when not searching)
 			if m.searchQuery != "" {
-				m.searchQuery = ""
+				m.clearSearch()
 				m.cursor = 0
 				return m, nil
 			}
@@ -295,11 +754,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor++
 			}
 
+		case key.Matches(msg, keys.PageUp):
+			m.cursor = max(0, m.cursor-m.pageStep(m.filteredProcesses()))
+
+		case key.Matches(msg, keys.PageDown):
+			filtered := m.filteredProcesses()
+			m.cursor = max(0, min(len(filtered)-1, m.cursor+m.pageStep(filtered)))
+
+		case key.Matches(msg, keys.Home):
+			m.cursor = 0
+
+		case key.Matches(msg, keys.End):
+			m.cursor = max(0, len(m.filteredProcesses())-1)
+
 		case key.Matches(msg, keys.Select):
 			filtered := m.filteredProcesses()
 			if len(filtered) > 0 && m.cursor < len(filtered) {
-				p := filtered[m.cursor]
-				m.selected[p.PID] = !m.selected[p.PID]
+				k := m.rowKey(filtered[m.cursor])
+				m.selected[k] = !m.selected[k]
 			}
 
 		case key.Matches(msg, keys.SelectAll):
@@ -307,14 +779,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Check if all are selected
 			allSelected := true
 			for _, p := range filtered {
-				if !m.selected[p.PID] {
+				if !m.selected[m.rowKey(p)] {
 					allSelected = false
 					break
 				}
 			}
 			// Toggle all
 			for _, p := range filtered {
-				m.selected[p.PID] = !allSelected
+				m.selected[m.rowKey(p)] = !allSelected
 			}
 
 		case key.Matches(msg, keys.Kill):
@@ -322,6 +794,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(filtered) == 0 {
 				return m, nil
 			}
+			if len(m.pending) > 0 {
+				m.statusMessage = "Wait for the pending kill to finish, or press u to cancel it"
+				m.statusTime = time.Now()
+				return m, nil
+			}
 
 			// If we have selected items, kill those; otherwise kill current
 			selected := m.getSelectedProcesses()
@@ -329,22 +806,76 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.toKill = selected
 				m.confirming = true
 			} else if m.cursor < len(filtered) {
-				m.toKill = []Process{filtered[m.cursor]}
+				m.toKill = uniqueProcesses(filtered[m.cursor:m.cursor+1], m.processes)
 				m.confirming = true
 			}
 
+		case key.Matches(msg, keys.Restart):
+			filtered := m.filteredProcesses()
+			if len(filtered) == 0 || m.cursor >= len(filtered) {
+				return m, nil
+			}
+			if len(m.pending) > 0 {
+				m.statusMessage = "Wait for the pending kill to finish, or press u to cancel it"
+				m.statusTime = time.Now()
+				return m, nil
+			}
+			m.toKill = uniqueProcesses(filtered[m.cursor:m.cursor+1], m.processes)
+			m.confirming = true
+			m.restarting = true
+			m.restartSplit = !hasExactArgv(m.toKill[0].PID)
+
+		case key.Matches(msg, keys.Recent):
+			if len(m.recent) == 0 {
+				m.statusMessage = "No processes killed yet"
+				m.statusTime = time.Now()
+				return m, nil
+			}
+			m.showRecent = true
+			m.recentCursor = 0
+
+		case key.Matches(msg, keys.Sort):
+			return m, m.setSort(m.sort.next())
+
+		case key.Matches(msg, keys.Detail):
+			m.showDetail = !m.showDetail
+			m.detailFor = 0
+
+		case key.Matches(msg, keys.PaneUp):
+			m.scrollDetail(-1)
+
+		case key.Matches(msg, keys.PaneDown):
+			m.scrollDetail(1)
+
+		case key.Matches(msg, keys.Listeners):
+			m.toggleListenerView()
+
+		case key.Matches(msg, keys.Columns):
+			m.pickingColumns = true
+			m.columnPicker = columnChoices(m.columns)
+			m.pickerCursor = 0
+
+		case key.Matches(msg, keys.Reverse):
+			return m, m.setSort(m.sort.reversed())
+
+		case key.Matches(msg, keys.DryRun):
+			m.dryRun = !m.dryRun
+			if m.dryRun {
+				m.statusMessage = "Dry run on: kills will be recorded, not sent"
+			} else {
+				m.statusMessage = "Dry run off: kills will be sent"
+			}
+			m.statusTime = time.Now()
+
 		case key.Matches(msg, keys.Refresh):
 			m.statusMessage = "Refreshing..."
 			m.statusTime = time.Now()
 			return m, m.refreshPorts()
 
 		case key.Matches(msg, keys.Toggle):
-			m.showSystemPorts = !m.showSystemPorts
-			// Adjust cursor if needed
-			filtered := m.filteredProcesses()
-			if m.cursor >= len(filtered) {
-				m.cursor = max(0, len(filtered)-1)
-			}
+			m.keepFocus(func() {
+				m.showSystemPorts = !m.showSystemPorts
+			})
 			if m.showSystemPorts {
 				m.statusMessage = "Showing all ports"
 			} else {
@@ -374,46 +905,93 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.lastError = nil
 
-		m.processes = msg.processes
-		// Sort by lowest port number
-		sort.Slice(m.processes, func(i, j int) bool {
-			return m.processes[i].LowestPort() < m.processes[j].LowestPort()
+		if m.scanned {
+			m.trackChanges(m.processes, msg.processes, time.Now())
+		}
+		m.scanned = true
+		m.keepFocus(func() {
+			m.processes = msg.processes
+			m.procTable = msg.table
+			m.extras = msg.extras
+			sortProcesses(m.processes, m.sort, m.procTable)
+			m.listVersion++
 		})
-		// Clean up selected map - remove PIDs that no longer exist
-		existingPIDs := make(map[int]bool)
-		for _, p := range m.processes {
-			existingPIDs[p.PID] = true
-		}
-		for pid := range m.selected {
-			if !existingPIDs[pid] {
-				delete(m.selected, pid)
+		// Clean up selected map - remove rows that no longer exist
+		existing := make(map[rowKey]bool)
+		for _, p := range m.rows() {
+			existing[m.rowKey(p)] = true
+		}
+		for k := range m.selected {
+			if !existing[k] {
+				delete(m.selected, k)
 			}
 		}
 
+		// Resource usage comes with the process table; the rest of the details
+		// needs lsof and ps on some systems, so it is read again less often
+		if info, ok := m.procTable[m.detail.PID]; ok {
+			m.detail.Info = info
+		}
+		if time.Since(m.detailRead) >= DetailRereadInterval {
+			m.detailFor = 0
+		}
+
 		// Apply initial filter from CLI argument (only once)
 		if m.initialFilter != "" && !m.filterApplied {
 			m.filterApplied = true
 			m.applyInitialFilter()
 		}
 
-		// Adjust cursor if list shrunk
-		filtered := m.filteredProcesses()
-		if m.cursor >= len(filtered) {
-			m.cursor = max(0, len(filtered)-1)
+	case detailMsg:
+		// Details of a process that lost focus in the meantime are dropped
+		if msg.detail.PID == m.detailFor {
+			m.detail = msg.detail
 		}
 
+	case countdownMsg:
+		if msg.id != m.pendingID || len(m.pending) == 0 {
+			return m, nil
+		}
+		if time.Now().Before(m.killAt) {
+			return m, m.countdownCmd()
+		}
+		procs := m.stillListening(m.pending)
+		m.pending = nil
+		if len(procs) == 0 {
+			m.statusMessage = "Nothing to kill: the processes already exited"
+			m.statusTime = time.Now()
+			return m, nil
+		}
+		if m.pendingRestart {
+			m.statusMessage = fmt.Sprintf("Restarting %s...", processLabel(procs[0]))
+			m.statusTime = time.Now()
+			return m, m.restartProcess(procs[0])
+		}
+		return m, m.startKill(procs)
+
 	case killResultMsg:
+		m.recordKill(msg)
 		m.killIndex++
+		if msg.auditErr != nil {
+			m.auditErr = msg.auditErr
+		}
+		if msg.success && msg.spec != nil {
+			m.rememberKilled(*msg.spec)
+		}
 
 		if msg.success {
-			// Remove from selected
-			delete(m.selected, msg.pid)
+			// Remove every row of the process from selected
+			for k := range m.selected {
+				if k.PID == msg.pid {
+					delete(m.selected, k)
+				}
+			}
 		}
 
 		// Check if more to kill
 		if m.killIndex < len(m.toKill) {
 			p := m.toKill[m.killIndex]
-			return m, m.killProcess(p.PID, p.LowestPort(), len(m.toKill)-m.killIndex-1)
+			return m, m.killProcess(p, len(m.toKill)-m.killIndex-1)
 		}
 
 		// All done
@@ -421,24 +999,422 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.toKill = nil
 		m.killIndex = 0
 
-		if killCount == 1 {
-			if msg.success {
-				m.statusMessage = fmt.Sprintf("Killed process on port %d", msg.port)
-			} else {
-				m.statusMessage = fmt.Sprintf("Failed to kill process %d", msg.pid)
-			}
-		} else {
+		switch {
+		case msg.dryRun && killCount == 1:
+			m.statusMessage = fmt.Sprintf("Would send %s to process %d on port %d (dry run)", m.killLog[len(m.killLog)-1].Strategy, msg.pid, msg.port)
+		case msg.dryRun:
+			m.statusMessage = fmt.Sprintf("Would signal %d processes (dry run)", killCount)
+		case killCount == 1 && msg.success && msg.err != nil:
+			m.statusMessage = fmt.Sprintf("Killed process on port %d (%v)", msg.port, msg.err)
+		case killCount == 1 && msg.success:
+			m.statusMessage = fmt.Sprintf("Killed process on port %d", msg.port)
+		case killCount == 1:
+			m.statusMessage = fmt.Sprintf("Failed to kill process %d", msg.pid)
+		default:
 			m.statusMessage = fmt.Sprintf("Killed %d processes", killCount)
 		}
+		if m.auditErr != nil {
+			m.statusMessage += fmt.Sprintf(" (not recorded in the audit log: %v)", m.auditErr)
+			m.auditErr = nil
+		}
 		m.statusTime = time.Now()
 		return m, m.refreshPorts()
+
+	case restartResultMsg:
+		if msg.killed != nil {
+			m.killLog = append(m.killLog, *msg.killed)
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to restart %s: %v", msg.label, msg.err)
+		} else {
+			m.statusMessage = fmt.Sprintf("Started %s as pid %d (log: %s)", msg.label, msg.pid, msg.logPath)
+		}
+		if msg.auditErr != nil {
+			m.statusMessage += fmt.Sprintf(" (not recorded in the audit log: %v)", msg.auditErr)
+		}
+		m.statusTime = time.Now()
+		return m, m.refreshPorts()
+
+	default:
+		// Messages for the search bar, such as text pasted from the clipboard
+		if m.searching {
+			return m, m.updateSearchInput(msg)
+		}
 	}
 
 	return m, nil
 }
 
+// killScopeNote explains, in the listener view, that killing a process closes
+// more listeners than the ones picked. It is empty when nothing else closes.
+func (m Model) killScopeNote() string {
+	if !m.perListener || len(m.toKill) == 0 {
+		return ""
+	}
+	picked := max(1, m.selectedCount())
+	total := listenerCount(m.toKill)
+	if total <= picked {
+		return ""
+	}
+	if len(m.toKill) == 1 {
+		return fmt.Sprintf("Killing affects the whole process, closing all %d of its listeners", total)
+	}
+	return fmt.Sprintf("The %d selected listeners belong to %d processes; killing them closes all %d of their listeners", picked, len(m.toKill), total)
+}
+
+// killStrategies returns the distinct strategies used for the processes to kill
+func (m Model) killStrategies() []string {
+	var strategies []string
+	for _, p := range m.toKill {
+		if s := killDescription(m.killer, p); !slices.Contains(strategies, s) {
+			strategies = append(strategies, s)
+		}
+	}
+	return strategies
+}
+
+// setSort re-sorts the process list and returns a command saving the order for the next session
+func (m *Model) setSort(order sortOrder) tea.Cmd {
+	m.keepFocus(func() {
+		m.sort = order
+		sortProcesses(m.processes, m.sort, m.procTable)
+		m.listVersion++
+	})
+	m.statusMessage = fmt.Sprintf("Sorted by %s %s", order.Column, order.indicator())
+	m.statusTime = time.Now()
+	return func() tea.Msg {
+		saveSortOrder(order)
+		return nil
+	}
+}
+
+// toggleColumn shows or hides the column under the picker cursor. Showing a
+// column whose details are not gathered yet returns a command fetching them.
+func (m *Model) toggleColumn() tea.Cmd {
+	choice := &m.columnPicker[m.pickerCursor]
+	if choice.Shown && len(m.columns) == 1 {
+		m.statusMessage = "At least one column has to be shown"
+		m.statusTime = time.Now()
+		return nil
+	}
+	choice.Shown = !choice.Shown
+	m.columns = shownColumns(m.columnPicker)
+	if choice.Shown && (choice.ID == colCwd || choice.ID == colProject || choice.ID == colConns) {
+		return m.refreshPorts()
+	}
+	return nil
+}
+
+// toggleListenerView switches between one row per process and one row per
+// listener. Selected processes stay selected with all their rows, and the
+// cursor stays on the same process.
+func (m *Model) toggleListenerView() {
+	pids := make(map[int]bool)
+	for k, ok := range m.selected {
+		if ok {
+			pids[k.PID] = true
+		}
+	}
+	before := m.filteredProcesses()
+	m.perListener = !m.perListener
+	m.cursor = followFocus(before, m.cursor, m.filteredProcesses(), processKey)
+
+	m.selected = make(map[rowKey]bool)
+	for _, p := range m.rows() {
+		if pids[p.PID] {
+			m.selected[m.rowKey(p)] = true
+		}
+	}
+
+	if m.perListener {
+		m.statusMessage = "One row per listener"
+	} else {
+		m.statusMessage = "One row per process"
+	}
+	m.statusTime = time.Now()
+}
+
+// moveColumn moves the column under the picker cursor up (delta -1) or down
+// (delta 1), changing where it is shown
+func (m *Model) moveColumn(delta int) {
+	i, j := m.pickerCursor, m.pickerCursor+delta
+	if j < 0 || j >= len(m.columnPicker) {
+		return
+	}
+	m.columnPicker[i], m.columnPicker[j] = m.columnPicker[j], m.columnPicker[i]
+	m.pickerCursor = j
+	m.columns = shownColumns(m.columnPicker)
+}
+
+// trackChanges records which processes started or stopped listening between
+// two scans and forgets changes older than their display time
+func (m *Model) trackChanges(before, after []Process, now time.Time) {
+	for pid, at := range m.appeared {
+		if now.Sub(at) >= NewRowHighlight {
+			delete(m.appeared, pid)
+		}
+	}
+	m.ghosts = slices.DeleteFunc(m.ghosts, func(g ghost) bool {
+		return now.Sub(g.GoneAt) >= GhostDuration
+	})
+
+	appeared, vanished := diffProcesses(before, after)
+	for _, p := range appeared {
+		m.appeared[p.PID] = now
+		// A process that is back is no longer a ghost
+		m.ghosts = slices.DeleteFunc(m.ghosts, func(g ghost) bool { return g.PID == p.PID })
+	}
+	for _, p := range vanished {
+		m.ghosts = append(m.ghosts, ghost{Process: p, GoneAt: now})
+	}
+}
+
+// diffProcesses returns the processes only in after and the processes only in before, by PID
+func diffProcesses(before, after []Process) (appeared, vanished []Process) {
+	inBefore := make(map[int]bool, len(before))
+	for _, p := range before {
+		inBefore[p.PID] = true
+	}
+	inAfter := make(map[int]bool, len(after))
+	for _, p := range after {
+		inAfter[p.PID] = true
+		if !inBefore[p.PID] {
+			appeared = append(appeared, p)
+		}
+	}
+	for _, p := range before {
+		if !inAfter[p.PID] {
+			vanished = append(vanished, p)
+		}
+	}
+	return appeared, vanished
+}
+
+// keepFocus runs change, which replaces or reorders the listed processes, and
+// moves the cursor so it stays on the same process
+func (m *Model) keepFocus(change func()) {
+	before := m.filteredProcesses()
+	change()
+	m.cursor = followFocus(before, m.cursor, m.filteredProcesses(), m.rowKey)
+}
+
+// followFocus returns the index in after of the row at cursor in before, as
+// identified by key. If that row is gone, it returns the index of its nearest
+// neighbour in before that is still listed, preferring the one below it.
+func followFocus(before []Process, cursor int, after []Process, key func(Process) rowKey) int {
+	if len(after) == 0 {
+		return 0
+	}
+	index := make(map[rowKey]int, len(after))
+	for i, p := range slices.Backward(after) {
+		// The first of several rows with the same key wins
+		index[key(p)] = i
+	}
+	if cursor >= 0 && cursor < len(before) {
+		for d := range len(before) {
+			for _, i := range []int{cursor + d, cursor - d} {
+				if i < 0 || i >= len(before) {
+					continue
+				}
+				if j, ok := index[key(before[i])]; ok {
+					return j
+				}
+			}
+		}
+	}
+	return min(max(cursor, 0), len(after)-1)
+}
+
+// rememberKilled adds a killed process to the front of the recently killed list
+func (m *Model) rememberKilled(spec launchSpec) {
+	spec.KilledAt = time.Now()
+	m.recent = append([]launchSpec{spec}, m.recent...)
+	if len(m.recent) > MaxRecentKills {
+		m.recent = m.recent[:MaxRecentKills]
+	}
+}
+
+// recordKill adds the result of a kill to the session's kill log
+func (m *Model) recordKill(msg killResultMsg) {
+	record := killRecord{PID: msg.pid, Port: msg.port, Err: msg.err, DryRun: msg.dryRun}
+	if m.killIndex < len(m.toKill) {
+		p := m.toKill[m.killIndex]
+		record.Command = processLabel(p)
+		record.Strategy = killDescription(m.killer, p)
+	}
+	m.killLog = append(m.killLog, record)
+}
+
+// visibleRows returns the range of the filtered rows to render. The window
+// starts at the scroll offset and moves just enough to keep the cursor visible.
+func (m Model) visibleRows(filtered []Process) (start, end int) {
+	total := len(filtered)
+	page := m.pageSize(filtered)
+	if page <= 0 || total <= page {
+		return 0, total
+	}
+	start = min(m.offset, total-page)
+	if m.cursor < start {
+		start = m.cursor
+	}
+	if m.cursor >= start+page {
+		start = m.cursor - page + 1
+	}
+	return start, start + page
+}
+
+// pageSize returns how many of the filtered rows fit on screen, or 0 for no limit
+func (m Model) pageSize(filtered []Process) int {
+	rows := 0
+	if m.height > 0 {
+		chrome := listHeaderLines + renderedHeight(m.viewFooter(filtered), m.width)
+		if placement := m.detailPlacement(); placement == detailBottom {
+			_, paneHeight := m.detailSize(placement)
+			chrome += paneHeight
+		}
+		rows = max(1, m.height-chrome)
+	}
+	if m.maxRows > 0 && (rows == 0 || m.maxRows < rows) {
+		rows = m.maxRows
+	}
+	return rows
+}
+
+// pageStep returns how far page up and page down move the cursor
+func (m Model) pageStep(filtered []Process) int {
+	if page := m.pageSize(filtered); page > 0 {
+		return page
+	}
+	return len(filtered)
+}
+
+// focused returns the process under the cursor, if any
+func (m Model) focused() (Process, bool) {
+	filtered := m.filteredProcesses()
+	if m.cursor < 0 || m.cursor >= len(filtered) {
+		return Process{}, false
+	}
+	return filtered[m.cursor], true
+}
+
+// detailPlacement returns where the detail pane is drawn
+func (m Model) detailPlacement() detailPlacement {
+	if !m.showDetail {
+		return detailHidden
+	}
+	if _, ok := m.focused(); !ok {
+		return detailHidden
+	}
+	if m.width >= DetailPaneMinWidth {
+		return detailRight
+	}
+	return detailBottom
+}
+
+// detailSize returns the width and height of the detail pane, border included.
+// Beside the list it is as high as the column header and the rows; below it,
+// it takes a third of the terminal.
+func (m Model) detailSize(placement detailPlacement) (width, height int) {
+	switch placement {
+	case detailRight:
+		width = max(DetailPaneMinColumns, m.width*2/5)
+		if page := m.pageSize(m.filteredProcesses()); page > 0 {
+			return width, page + 1
+		}
+		return width, DetailPaneMaxLines
+	case detailBottom:
+		width = m.width
+		if width <= 0 {
+			width = DefaultFullCommandWidth
+		}
+		if m.height > 0 {
+			return width, max(DetailPaneMinLines, m.height/3)
+		}
+		return width, DetailPaneMaxLines
+	}
+	return 0, 0
+}
+
+// listWidth returns the width left for the process list, 0 if unknown
+func (m Model) listWidth() int {
+	if placement := m.detailPlacement(); placement == detailRight {
+		width, _ := m.detailSize(placement)
+		return m.width - width
+	}
+	return m.width
+}
+
+// detailContent returns the lines of the detail pane and how many of them fit
+func (m Model) detailContent(placement detailPlacement) (lines []string, fit int) {
+	width, height := m.detailSize(placement)
+	p, _ := m.focused()
+	// A listener row shows every listener of its process
+	p = uniqueProcesses([]Process{p}, m.processes)[0]
+	// Padding and border take two columns; the heading, and the top border below the list, take lines
+	lines = detailLines(p, m.detail, m.fuzzyPositions(p)[fuzzyRawCommand], time.Now(), width-2)
+	fit = height - 1
+	if placement == detailBottom {
+		fit--
+	}
+	return lines, max(1, fit)
+}
+
+// scrollDetail scrolls the detail pane by delta lines
+func (m *Model) scrollDetail(delta int) {
+	placement := m.detailPlacement()
+	if placement == detailHidden {
+		return
+	}
+	lines, fit := m.detailContent(placement)
+	m.detailOffset = max(0, min(m.detailOffset+delta, len(lines)-fit))
+}
+
+// viewDetail renders the detail pane of the focused process
+func (m Model) viewDetail(placement detailPlacement) string {
+	width, height := m.detailSize(placement)
+	lines, fit := m.detailContent(placement)
+	start := max(0, min(m.detailOffset, len(lines)-fit))
+	end := min(len(lines), start+fit)
+
+	p, _ := m.focused()
+	heading := detailHeadingStyle.Render(fmt.Sprintf("%s · pid %d", p.Name, p.PID))
+	if end-start < len(lines) {
+		heading += " " + scrollStyle.Render(fmt.Sprintf("%d–%d of %d • [/] scroll", start+1, end, len(lines)))
+	}
+	content := ansi.Truncate(heading, width-2, "…") + "\n" + strings.Join(lines[start:end], "\n")
+
+	if placement == detailRight {
+		return detailPaneStyle.BorderLeft(true).Width(width - 1).Height(height).Render(content)
+	}
+	return detailPaneStyle.BorderTop(true).Width(width).Height(height - 1).Render(content)
+}
+
+// renderedHeight returns how many terminal lines s takes up, counting lines
+// that wrap because they are wider than the terminal.
+func renderedHeight(s string, width int) int {
+	height := 0
+	for _, line := range strings.Split(s, "\n") {
+		if w := lipgloss.Width(line); width > 0 && w > width {
+			height += (w + width - 1) / width
+		} else {
+			height++
+		}
+	}
+	return height
+}
+
 // View renders the UI
 func (m Model) View() string {
+	if m.showRecent {
+		return m.recentView()
+	}
+	if m.pickingColumns {
+		return m.columnsView()
+	}
+
+	filtered := m.filteredProcesses()
+	start, end := m.visibleRows(filtered)
+
 	var sb strings.Builder
 
 	// Title with selection count
@@ -448,21 +1424,71 @@ func (m Model) View() string {
 	} else {
 		title += " (user ports)"
 	}
+	if m.perListener {
+		title = strings.TrimSuffix(title, ")") + ", per listener)"
+	}
 	if count := m.selectedCount(); count > 0 {
 		title += " " + selectedCountStyle.Render(fmt.Sprintf("[%d selected]", count))
 	}
+	if m.dryRun {
+		title += " " + dryRunStyle.Render("[dry run]")
+	}
+	if end-start < len(filtered) {
+		title += " " + scrollStyle.Render(fmt.Sprintf("%d–%d of %d", start+1, end, len(filtered)))
+	}
 	sb.WriteString(titleStyle.Render(title))
 	sb.WriteByte('\n')
 
+	list := m.viewList(filtered, start, end)
+	switch placement := m.detailPlacement(); placement {
+	case detailRight:
+		list = lipgloss.NewStyle().Width(m.listWidth()).Render(strings.TrimSuffix(list, "\n"))
+		list = lipgloss.JoinHorizontal(lipgloss.Top, list, m.viewDetail(placement)) + "\n"
+	case detailBottom:
+		list += m.viewDetail(placement) + "\n"
+	}
+	sb.WriteString(list)
+
+	sb.WriteString(m.viewFooter(filtered))
+	return sb.String()
+}
+
+// viewList renders the column header and the visible rows of the process list
+func (m Model) viewList(filtered []Process, start, end int) string {
+	var sb strings.Builder
+
+	shown := m.listColumns()
+	cols := make([]column, len(shown))
+	titles := make([]string, len(shown))
+	for i, id := range shown {
+		cols[i], _ = findColumn(id)
+		titles[i] = m.columnTitle(cols[i].sort, cols[i].title)
+	}
+	now := time.Now()
+	values := make([][]string, end-start)
+	for i, p := range filtered[start:end] {
+		values[i] = columnValues(cols, m.columnRow(p, now))
+	}
+	widths := layoutColumns(cols, m.listWidths(shown, cols, titles, now), max(0, m.listWidth()-checkboxWidth))
+
 	// Header
-	header := fmt.Sprintf("    %-18s %-8s %-15s %-12s %s",
-		"PORT", "PID", "PROCESS", "USER", "COMMAND")
-	sb.WriteString(headerStyle.Render(header))
+	var header []string
+	sortShown := false
+	for i, title := range titles {
+		if widths[i] > 0 {
+			header = append(header, truncate(title, widths[i]))
+			sortShown = sortShown || cols[i].sort == m.sort.Column
+		}
+	}
+	headerLine := strings.Repeat(" ", checkboxWidth) + strings.Join(header, " ")
+	// The sorted column may be hidden, or have no column at all
+	if !sortShown {
+		headerLine += fmt.Sprintf("  (by %s %s)", m.sort.Column, m.sort.indicator())
+	}
+	sb.WriteString(headerStyle.Render(headerLine))
 	sb.WriteByte('\n')
 
 	// Process list
-	filtered := m.filteredProcesses()
-
 	if len(filtered) == 0 {
 		if m.searchQuery != "" {
 			sb.WriteString(emptyStyle.Render(fmt.Sprintf("No processes match '%s'", m.searchQuery)))
@@ -471,45 +1497,108 @@ func (m Model) View() string {
 		}
 		sb.WriteByte('\n')
 	} else {
-		for i, p := range filtered {
+		for i := start; i < end; i++ {
+			p := filtered[i]
+
 			// Checkbox
 			checkbox := checkboxUnchecked
-			if m.selected[p.PID] {
+			if m.selected[m.rowKey(p)] {
 				checkbox = checkboxChecked
 			}
 
-			// Format command for display using smart formatters
-			cmd := formatCommand(p.Command)
-			maxCmdLen := DefaultCommandWidth
-			if m.width > MinTerminalWidth {
-				maxCmdLen = m.width - ColumnWidthOffset
-			}
-			if len(cmd) > maxCmdLen {
-				cmd = cmd[:maxCmdLen-3] + "..."
+			matched := m.fuzzyPositions(p)
+			cells := []string{checkbox}
+			for j, c := range cols {
+				if widths[j] == 0 {
+					continue
+				}
+				var positions []int
+				if c.highlight {
+					positions = matched[c.fuzzy]
+				}
+				cells = append(cells, renderCell(values[i-start][j], widths[j], positions, c.style))
 			}
-
-			line := fmt.Sprintf("%s %s %s %s %s %s",
-				checkbox,
-				portStyle.Render(fmt.Sprintf("%-18s", formatPorts(p.Ports, 18))),
-				pidStyle.Render(fmt.Sprintf("%-8d", p.PID)),
-				nameStyle.Render(truncate(p.Name, 15)),
-				userStyle.Render(truncate(p.User, 12)),
-				commandStyle.Render(cmd),
-			)
+			line := strings.Join(cells, " ")
 
 			if i == m.cursor {
 				sb.WriteString(selectedStyle.Render(line))
-			} else if m.selected[p.PID] {
+			} else if m.selected[m.rowKey(p)] {
 				sb.WriteString(checkedStyle.Render(line))
+			} else if at, ok := m.appeared[p.PID]; ok && time.Since(at) < NewRowHighlight {
+				sb.WriteString(newRowStyle.Render(line))
 			} else {
 				sb.WriteString(normalStyle.Render(line))
 			}
 			sb.WriteByte('\n')
 		}
 	}
+	return sb.String()
+}
+
+// listWidths returns the natural widths of the shown columns. They fit the
+// values of every listed process, not only the visible ones, so they do not
+// change while scrolling, and are measured once per change of the list.
+func (m Model) listWidths(shown []columnID, cols []column, titles []string, now time.Time) []int {
+	list := m.filteredList()
+	if list.widths != nil && slices.Equal(list.widthsFor, shown) {
+		return list.widths
+	}
+	values := make([][]string, len(list.processes))
+	for i, p := range list.processes {
+		values[i] = columnValues(cols, m.columnRow(p, now))
+	}
+	list.widths, list.widthsFor = naturalWidths(cols, titles, values), slices.Clone(shown)
+	return list.widths
+}
+
+// listColumns returns the columns the process list shows. The listener view
+// adds the address and protocol after the port, as they tell its rows apart.
+func (m Model) listColumns() []columnID {
+	if !m.perListener {
+		return m.columns
+	}
+	var extra []columnID
+	for _, id := range []columnID{colAddress, colProto} {
+		if !slices.Contains(m.columns, id) {
+			extra = append(extra, id)
+		}
+	}
+	at := slices.Index(m.columns, colPort) + 1
+	return slices.Concat(m.columns[:at], extra, m.columns[at:])
+}
+
+// columnTitle returns a header title, marked with an arrow if the list is sorted by its column
+func (m Model) columnTitle(column sortColumn, title string) string {
+	if column != "" && m.sort.Column == column {
+		return title + " " + m.sort.indicator()
+	}
+	return title
+}
+
+// columnRow returns what the column values of a process are computed from
+func (m Model) columnRow(p Process, now time.Time) columnRow {
+	extras, ok := m.extras[p.PID]
+	if !ok {
+		extras.Conns = -1
+	}
+	return columnRow{Process: p, info: m.procTable[p.PID], extras: extras, now: now}
+}
+
+// viewFooter renders everything below the process rows: the focused command,
+// prompts, status and help.
+func (m Model) viewFooter(filtered []Process) string {
+	var sb strings.Builder
+
+	// Processes that recently stopped listening
+	for _, g := range m.ghosts {
+		if time.Since(g.GoneAt) < GhostDuration && m.shows(g.Process) {
+			sb.WriteString(ghostStyle.Render(fmt.Sprintf(" ✕  %-18s %s exited", formatPorts(g.Ports, 18), g.Name)))
+			sb.WriteByte('\n')
+		}
+	}
 
-	// Show full command for focused row
-	if len(filtered) > 0 && m.cursor < len(filtered) && !m.confirming {
+	// Show full command for focused row, unless the detail pane shows it
+	if len(filtered) > 0 && m.cursor < len(filtered) && !m.confirming && !m.showDetail {
 		fullCmd := filtered[m.cursor].Command
 		// Truncate to terminal width if needed
 		maxLen := m.width - 4 // Account for "> " prefix and some padding
@@ -520,22 +1609,66 @@ func (m Model) View() string {
 			fullCmd = fullCmd[:maxLen-3] + "..."
 		}
 		sb.WriteByte('\n')
-		sb.WriteString(cmdDetailStyle.Render("> " + fullCmd))
+		matched := m.fuzzyPositions(filtered[m.cursor])
+		sb.WriteString(cmdDetailStyle.Render("> ") + highlightMatches(fullCmd, matched[fuzzyRawCommand], cmdDetailStyle))
 	}
 
 	// Confirmation prompt
 	if m.confirming {
-		if len(m.toKill) == 1 {
+		prefix := "\n"
+		if m.dryRun {
+			prefix = "\n[dry run] "
+		}
+		if m.restarting {
+			p := m.toKill[0]
+			if m.restartSplit {
+				prefix += "Note: " + splitArgvWarning + ".\n"
+			}
+			sb.WriteString(confirmStyle.Render(fmt.Sprintf(prefix+"Restart process %d (%s) with %s? (y/n)", p.PID, processLabel(p), killDescription(m.killer, p))))
+		} else if len(m.toKill) == 1 {
 			p := m.toKill[0]
 			portsStr := formatPorts(p.Ports, 40)
+			strategy := killDescription(m.killer, p)
 			if len(p.Ports) == 1 {
-				sb.WriteString(confirmStyle.Render(fmt.Sprintf("\nKill process %d on port %s? (y/n)", p.PID, portsStr)))
+				sb.WriteString(confirmStyle.Render(fmt.Sprintf(prefix+"Kill process %d on port %s with %s? (y/n)", p.PID, portsStr, strategy)))
 			} else {
-				sb.WriteString(confirmStyle.Render(fmt.Sprintf("\nKill process %d on ports %s? (y/n)", p.PID, portsStr)))
+				sb.WriteString(confirmStyle.Render(fmt.Sprintf(prefix+"Kill process %d on ports %s with %s? (y/n)", p.PID, portsStr, strategy)))
 			}
 		} else {
-			sb.WriteString(confirmStyle.Render(fmt.Sprintf("\nKill %d selected processes? (y/n)", len(m.toKill))))
+			strategies := m.killStrategies()
+			if len(strategies) == 1 {
+				sb.WriteString(confirmStyle.Render(fmt.Sprintf(prefix+"Kill %d selected processes with %s? (y/n)", len(m.toKill), strategies[0])))
+			} else {
+				// List the strategies since they differ between processes
+				sb.WriteString(confirmStyle.Render(fmt.Sprintf(prefix+"Kill %d selected processes? (y/n)", len(m.toKill))))
+				for _, p := range m.toKill {
+					sb.WriteString(fmt.Sprintf("\n  %-6d %s %s", p.LowestPort(), truncate(processLabel(p), 30), killDescription(m.killer, p)))
+				}
+			}
+		}
+		if note := m.killScopeNote(); note != "" {
+			sb.WriteByte('\n')
+			sb.WriteString(cmdDetailStyle.Render(note))
+		}
+	}
+
+	// Countdown of pending kills
+	if len(m.pending) > 0 {
+		remaining := max(0, time.Until(m.killAt).Round(time.Second))
+		what := fmt.Sprintf("process %d", m.pending[0].PID)
+		if len(m.pending) > 1 {
+			what = fmt.Sprintf("%d processes", len(m.pending))
+		}
+		verb := "Killing"
+		if m.pendingRestart {
+			verb = "Restarting"
 		}
+		banner := fmt.Sprintf("%s %s in %s • u/esc to cancel", verb, what, remaining)
+		if m.dryRun {
+			banner = "[dry run] " + banner
+		}
+		sb.WriteByte('\n')
+		sb.WriteString(pendingStyle.Render(banner))
 	}
 
 	// Status message (show for configured duration)
@@ -547,19 +1680,95 @@ func (m Model) View() string {
 	// Search bar or Help
 	if m.searching {
 		sb.WriteByte('\n')
-		sb.WriteString(searchStyle.Render("/" + m.searchQuery + "▌"))
+		sb.WriteString(m.searchInput.View())
+		switch {
+		case m.fuzzy:
+			sb.WriteString(" " + searchFilterStyle.Render("[fuzzy • ctrl+t for exact]"))
+		case m.searchErr != nil:
+			sb.WriteString(" " + searchErrorStyle.Render(m.searchErr.Error()))
+		case len(m.searchHistory) > 0:
+			sb.WriteString(" " + helpStyle.UnsetMarginTop().Render("↑/↓ history"))
+		}
 	} else if m.searchQuery != "" {
 		// Show filter indicator and modified help
 		sb.WriteByte('\n')
-		sb.WriteString(searchFilterStyle.Render(fmt.Sprintf("filter: %s", m.searchQuery)))
+		label := "filter"
+		if m.fuzzy {
+			label = "fuzzy filter"
+		}
+		sb.WriteString(searchFilterStyle.Render(fmt.Sprintf("%s: %s", label, m.searchQuery)))
 		help := "↑/k up • ↓/j down • space select • enter/d kill • / search • esc clear • q quit"
 		sb.WriteByte('\n')
 		sb.WriteString(helpStyle.Render(help))
 	} else {
-		help := "↑/k up • ↓/j down • space select • a select all • enter/d kill • / search • r refresh • s system ports • q quit"
+		help := "↑/k up • ↓/j down • space select • a select all • pgup/pgdn page • enter/d kill • / search • R restart • l recently killed • r refresh • o sort • O reverse • c columns • i details • v listeners • s system ports • D dry run • q quit"
 		sb.WriteByte('\n')
 		sb.WriteString(helpStyle.Render(help))
 	}
 
 	return sb.String()
 }
+
+// columnsView renders the column picker
+func (m Model) columnsView() string {
+	var sb strings.Builder
+
+	sb.WriteString(titleStyle.Render("portsweep (columns)"))
+	sb.WriteByte('\n')
+	for i, choice := range m.columnPicker {
+		c, _ := findColumn(choice.ID)
+		checkbox := checkboxUnchecked
+		if choice.Shown {
+			checkbox = checkboxChecked
+		}
+		line := fmt.Sprintf("%s %-8s %s", checkbox, c.title, c.desc)
+		if i == m.pickerCursor {
+			sb.WriteString(selectedStyle.Render(line))
+		} else {
+			sb.WriteString(normalStyle.Render(line))
+		}
+		sb.WriteByte('\n')
+	}
+
+	if m.statusMessage != "" && time.Since(m.statusTime) < StatusDisplayDuration {
+		sb.WriteByte('\n')
+		sb.WriteString(statusStyle.Render(m.statusMessage))
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(helpStyle.Render("↑/k up • ↓/j down • space show/hide • K/J move up/down • esc/c done • q quit"))
+	return sb.String()
+}
+
+// recentView renders the list of recently killed processes
+func (m Model) recentView() string {
+	var sb strings.Builder
+
+	sb.WriteString(titleStyle.Render("portsweep (recently killed)"))
+	sb.WriteByte('\n')
+	header := fmt.Sprintf("  %-8s %-18s %-30s %s", "KILLED", "PORT", "COMMAND", "DIRECTORY")
+	sb.WriteString(headerStyle.Render(header))
+	sb.WriteByte('\n')
+
+	for i, spec := range m.recent {
+		line := fmt.Sprintf("  %-8s %-18s %-30s %s",
+			spec.KilledAt.Format(time.TimeOnly),
+			formatPorts(spec.Ports, 18),
+			truncate(spec.Label, 30),
+			spec.Cwd,
+		)
+		if i == m.recentCursor {
+			sb.WriteString(selectedStyle.Render(line))
+		} else {
+			sb.WriteString(normalStyle.Render(line))
+		}
+		sb.WriteByte('\n')
+	}
+
+	if m.statusMessage != "" && time.Since(m.statusTime) < StatusDisplayDuration {
+		sb.WriteByte('\n')
+		sb.WriteString(statusStyle.Render(m.statusMessage))
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(helpStyle.Render("↑/k up • ↓/j down • enter relaunch • esc/l back • q quit"))
+	return sb.String()
+}
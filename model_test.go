@@ -0,0 +1,768 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// pressKey sends a key press to the model and returns the updated model
+func pressKey(t *testing.T, m Model, k string) (Model, tea.Cmd) {
+	t.Helper()
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+	if k == "esc" {
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	updated, cmd := m.Update(msg)
+	return updated.(Model), cmd
+}
+
+func TestKillDelayUndo(t *testing.T) {
+	m := NewModel(Options{KillDelay: time.Minute, DryRun: true})
+	updated, _ := m.Update(refreshMsg{processes: []Process{{PID: 123, Ports: []int{3000}, Name: "node"}}})
+	m = updated.(Model)
+
+	m, _ = pressKey(t, m, "d")
+	m, cmd := pressKey(t, m, "y")
+	if len(m.pending) != 1 || cmd == nil {
+		t.Fatalf("expected 1 pending kill and a countdown, got %d pending", len(m.pending))
+	}
+	if m.confirming {
+		t.Error("expected confirmation to end once the kill is pending")
+	}
+
+	// Refresh keeps running during the countdown
+	if _, cmd := m.Update(tickMsg(time.Now())); cmd == nil {
+		t.Error("expected tick to refresh while a kill is pending")
+	}
+
+	m, _ = pressKey(t, m, "u")
+	if len(m.pending) != 0 {
+		t.Fatalf("expected u to cancel the pending kill")
+	}
+
+	// A countdown tick of the cancelled batch sends nothing
+	updated, cmd = m.Update(countdownMsg{id: m.pendingID})
+	if cmd != nil || len(updated.(Model).killLog) != 0 {
+		t.Error("expected cancelled kill not to be sent")
+	}
+	if got := m.recorder.Killed(); len(got) != 0 {
+		t.Errorf("expected no kills, got %v", got)
+	}
+}
+
+func TestRestartWaitsForKillDelay(t *testing.T) {
+	m := NewModel(Options{KillDelay: time.Minute})
+	killer := &MockKiller{}
+	m.killer = killer
+	updated, _ := m.Update(refreshMsg{processes: []Process{{PID: 123, Ports: []int{3000}, Name: "node"}}})
+	m = updated.(Model)
+
+	m, _ = pressKey(t, m, "R")
+	m, cmd := pressKey(t, m, "y")
+	if len(m.pending) != 1 || !m.pendingRestart || cmd == nil {
+		t.Fatalf("expected the restart to wait for the kill delay, got %d pending", len(m.pending))
+	}
+	if !strings.Contains(m.View(), "Restarting process 123 in") {
+		t.Errorf("expected a restart countdown:\n%s", m.View())
+	}
+
+	m, _ = pressKey(t, m, "u")
+	updated, cmd = m.Update(countdownMsg{id: m.pendingID})
+	if len(m.pending) != 0 || cmd != nil || len(killer.KilledPIDs) != 0 {
+		t.Errorf("expected u to cancel the restart, got %d pending and kills %v", len(m.pending), killer.KilledPIDs)
+	}
+	if !strings.Contains(updated.(Model).statusMessage, "Cancelled restarting") {
+		t.Errorf("unexpected status %q", updated.(Model).statusMessage)
+	}
+}
+
+func TestDryRunRestartIsSummarised(t *testing.T) {
+	m := NewModel(Options{DryRun: true})
+	updated, _ := m.Update(refreshMsg{processes: []Process{{PID: 123, Ports: []int{3000}, Name: "node"}}})
+	m = updated.(Model)
+
+	m, _ = pressKey(t, m, "R")
+	m, _ = pressKey(t, m, "y")
+	var out strings.Builder
+	printKillSummary(&out, m.killLog)
+	if !strings.Contains(out.String(), "would send SIGTERM and restart (dry run)") {
+		t.Errorf("expected the restart in the exit summary, got:\n%s", out.String())
+	}
+}
+
+func TestKillDelayExpires(t *testing.T) {
+	m := NewModel(Options{KillDelay: time.Millisecond, DryRun: true})
+	updated, _ := m.Update(refreshMsg{processes: []Process{
+		{PID: 123, Ports: []int{3000}, Name: "node"},
+		{PID: 456, Ports: []int{4000}, Name: "vite"},
+	}})
+	m = updated.(Model)
+
+	m, _ = pressKey(t, m, "a")
+	m, _ = pressKey(t, m, "d")
+	m, _ = pressKey(t, m, "y")
+
+	// PID 456 exits during the countdown
+	updated, _ = m.Update(refreshMsg{processes: []Process{{PID: 123, Ports: []int{3000}, Name: "node"}}})
+	m = updated.(Model)
+
+	time.Sleep(2 * time.Millisecond)
+	updated, cmd := m.Update(countdownMsg{id: m.pendingID})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("expected the pending kill to start once the delay passed")
+	}
+	if _, ok := cmd().(killResultMsg); !ok {
+		t.Fatal("expected a kill result")
+	}
+	if got := m.recorder.Killed(); len(got) != 1 || got[0] != 123 {
+		t.Errorf("expected only PID 123 to be killed, got %v", got)
+	}
+}
+
+func TestRememberKilled(t *testing.T) {
+	m := NewModel(Options{})
+	for i := range MaxRecentKills + 2 {
+		updated, _ := m.Update(killResultMsg{success: true, pid: i, spec: &launchSpec{Argv: []string{"server"}, Ports: []int{3000 + i}}})
+		m = updated.(Model)
+	}
+	// A failed kill leaves the process running, so there is nothing to relaunch
+	updated, _ := m.Update(killResultMsg{pid: 99, spec: &launchSpec{Argv: []string{"server"}}})
+	m = updated.(Model)
+
+	if len(m.recent) != MaxRecentKills {
+		t.Fatalf("expected %d recent kills, got %d", MaxRecentKills, len(m.recent))
+	}
+	if m.recent[0].Ports[0] != 3000+MaxRecentKills+1 {
+		t.Errorf("expected the newest kill first, got port %d", m.recent[0].Ports[0])
+	}
+}
+
+// manyProcesses returns n processes listening on consecutive ports
+func manyProcesses(n int) []Process {
+	processes := make([]Process, n)
+	for i := range processes {
+		processes[i] = Process{PID: 1000 + i, Ports: []int{3000 + i}, Name: "node"}
+	}
+	return processes
+}
+
+func TestViewportScroll(t *testing.T) {
+	m := NewModel(Options{MaxRows: 10})
+	updated, _ := m.Update(refreshMsg{processes: manyProcesses(100)})
+	m = updated.(Model)
+
+	tests := []struct {
+		key        tea.KeyMsg
+		cursor     int
+		start, end int
+	}{
+		{tea.KeyMsg{Type: tea.KeyPgDown}, 10, 1, 11},
+		{tea.KeyMsg{Type: tea.KeyPgDown}, 20, 11, 21},
+		{tea.KeyMsg{Type: tea.KeyPgUp}, 10, 10, 20},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")}, 9, 9, 19},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")}, 99, 90, 100},
+		{tea.KeyMsg{Type: tea.KeyPgDown}, 99, 90, 100},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")}, 0, 0, 10},
+		{tea.KeyMsg{Type: tea.KeyPgUp}, 0, 0, 10},
+	}
+	for _, tt := range tests {
+		updated, _ := m.Update(tt.key)
+		m = updated.(Model)
+		start, end := m.visibleRows(m.filteredProcesses())
+		if m.cursor != tt.cursor || start != tt.start || end != tt.end {
+			t.Errorf("after %s: got cursor %d rows %d-%d, want cursor %d rows %d-%d",
+				tt.key, m.cursor, start, end, tt.cursor, tt.start, tt.end)
+		}
+	}
+
+	view := m.View()
+	if !strings.Contains(view, "1–10 of 100") {
+		t.Errorf("expected scroll indicator in view:\n%s", view)
+	}
+	if strings.Contains(view, "3010") {
+		t.Errorf("expected rows outside the viewport not to be rendered")
+	}
+}
+
+func TestViewportKeepsColumnWidths(t *testing.T) {
+	processes := manyProcesses(100)
+	processes[99].Name = "a-rather-long-process-name"
+	m := NewModel(Options{MaxRows: 10})
+	updated, _ := m.Update(refreshMsg{processes: processes})
+	m = updated.(Model)
+
+	header := strings.Split(m.View(), "\n")[2]
+	m, _ = pressKey(t, m, "G")
+	if got := strings.Split(m.View(), "\n")[2]; got != header {
+		t.Errorf("expected the header to keep its widths while scrolling:\n%q\n%q", header, got)
+	}
+	updated, _ = NewModel(Options{MaxRows: 10}).Update(refreshMsg{processes: manyProcesses(100)})
+	if short := strings.Split(updated.View(), "\n")[2]; short == header {
+		t.Errorf("expected the process column to widen for the row outside the viewport: %q", header)
+	}
+}
+
+func TestViewportFitsTerminal(t *testing.T) {
+	m := NewModel(Options{})
+	updated, _ := m.Update(refreshMsg{processes: manyProcesses(100)})
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = updated.(Model)
+
+	if got := renderedHeight(m.View(), m.width); got > m.height {
+		t.Errorf("expected view to fit %d lines, got %d", m.height, got)
+	}
+	if page := m.pageSize(m.filteredProcesses()); page < 10 {
+		t.Errorf("expected most of the terminal to be used for rows, got %d", page)
+	}
+}
+
+func TestRenderedHeight(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  int
+	}{
+		{"one line", 80, 1},
+		{"a\nb\n", 80, 3},
+		{strings.Repeat("x", 100), 40, 3},
+		{strings.Repeat("x", 100), 0, 1},
+	}
+	for _, tt := range tests {
+		if got := renderedHeight(tt.s, tt.width); got != tt.want {
+			t.Errorf("renderedHeight(%q, %d) = %d, want %d", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestSortKeys(t *testing.T) {
+	m := NewModel(Options{})
+	updated, _ := m.Update(refreshMsg{processes: []Process{
+		{PID: 200, Ports: []int{3000}, Name: "node"},
+		{PID: 100, Ports: []int{4000}, Name: "vite"},
+	}})
+	m = updated.(Model)
+
+	m, cmd := pressKey(t, m, "o")
+	if m.sort != (sortOrder{Column: sortPID}) || cmd == nil {
+		t.Fatalf("expected o to sort by PID and save the order, got %+v", m.sort)
+	}
+	if m.processes[0].PID != 100 {
+		t.Errorf("expected PID 100 first, got %d", m.processes[0].PID)
+	}
+	if !strings.Contains(m.View(), "PID ▲") {
+		t.Errorf("expected sort indicator in the header")
+	}
+
+	m, _ = pressKey(t, m, "O")
+	if !m.sort.Descending || m.processes[0].PID != 200 {
+		t.Errorf("expected O to reverse the order, got %+v with PID %d first", m.sort, m.processes[0].PID)
+	}
+
+	// The order survives a refresh
+	updated, _ = m.Update(refreshMsg{processes: []Process{
+		{PID: 100, Ports: []int{4000}, Name: "vite"},
+		{PID: 200, Ports: []int{3000}, Name: "node"},
+	}})
+	if got := updated.(Model).processes[0].PID; got != 200 {
+		t.Errorf("expected the sort order to be kept on refresh, got PID %d first", got)
+	}
+}
+
+// pids returns processes with the given PIDs, each listening on port PID+1000
+func pids(ids ...int) []Process {
+	processes := make([]Process, len(ids))
+	for i, id := range ids {
+		processes[i] = Process{PID: id, Ports: []int{id + 1000}}
+	}
+	return processes
+}
+
+func TestFollowFocus(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []Process
+		cursor int
+		after  []Process
+		want   int
+	}{
+		{"row inserted above", pids(1, 2, 3), 1, pids(0, 1, 2, 3), 2},
+		{"row removed above", pids(1, 2, 3), 2, pids(2, 3), 1},
+		{"reordered", pids(1, 2, 3), 0, pids(3, 2, 1), 2},
+		{"focused gone, neighbour below", pids(1, 2, 3), 1, pids(1, 3), 1},
+		{"focused gone, neighbour above", pids(1, 2, 3), 2, pids(4, 1, 2), 2},
+		{"all neighbours gone", pids(1, 2), 1, pids(7, 8, 9), 1},
+		{"list emptied", pids(1, 2), 1, nil, 0},
+		{"first load", nil, 0, pids(1, 2), 0},
+		{"first of several rows", pids(1, 2), 1, pids(1, 2, 2), 1},
+	}
+	for _, tt := range tests {
+		if got := followFocus(tt.before, tt.cursor, tt.after, processKey); got != tt.want {
+			t.Errorf("%s: followFocus() = %d, expected %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCursorFollowsProcessOnRefresh(t *testing.T) {
+	m := NewModel(Options{})
+	updated, _ := m.Update(refreshMsg{processes: pids(3000, 4000, 5000)})
+	m = updated.(Model)
+	m, _ = pressKey(t, m, "j")
+
+	// A new server starts on a lower port, shifting every row down
+	updated, _ = m.Update(refreshMsg{processes: pids(2000, 3000, 4000, 5000)})
+	m = updated.(Model)
+	if got := m.filteredProcesses()[m.cursor].PID; got != 4000 {
+		t.Errorf("expected cursor to stay on PID 4000, got %d", got)
+	}
+
+	// Sorting by PID descending moves it again
+	m, _ = pressKey(t, m, "o")
+	m, _ = pressKey(t, m, "O")
+	if got := m.filteredProcesses()[m.cursor].PID; got != 4000 {
+		t.Errorf("expected cursor to stay on PID 4000 after sorting, got %d", got)
+	}
+}
+
+func TestTrackChanges(t *testing.T) {
+	m := NewModel(Options{})
+	updated, _ := m.Update(refreshMsg{processes: []Process{
+		{PID: 10, Ports: []int{3000}, Name: "vite"},
+		{PID: 20, Ports: []int{4000}, Name: "api"},
+	}})
+	m = updated.(Model)
+	if len(m.appeared) != 0 {
+		t.Errorf("expected the first scan not to highlight anything, got %v", m.appeared)
+	}
+
+	// vite was restarted under a new PID
+	updated, _ = m.Update(refreshMsg{processes: []Process{
+		{PID: 11, Ports: []int{3000}, Name: "vite"},
+		{PID: 20, Ports: []int{4000}, Name: "api"},
+	}})
+	m = updated.(Model)
+	if _, ok := m.appeared[11]; !ok || len(m.appeared) != 1 {
+		t.Errorf("expected only PID 11 to be highlighted, got %v", m.appeared)
+	}
+	if len(m.ghosts) != 1 || m.ghosts[0].PID != 10 {
+		t.Fatalf("expected PID 10 as a ghost, got %+v", m.ghosts)
+	}
+	if view := m.View(); !strings.Contains(view, "✕") || !strings.Contains(view, "vite exited") {
+		t.Errorf("expected a ghost row in the view:\n%s", view)
+	}
+
+	// Highlights and ghosts expire
+	m.trackChanges(m.processes, m.processes, time.Now().Add(GhostDuration))
+	if len(m.appeared) != 0 || len(m.ghosts) != 0 {
+		t.Errorf("expected changes to expire, got %v and %+v", m.appeared, m.ghosts)
+	}
+}
+
+func TestFuzzySearchToggle(t *testing.T) {
+	m := NewModel(Options{})
+	updated, _ := m.Update(refreshMsg{processes: []Process{
+		{PID: 1, Ports: []int{3000}, Name: "node", Command: "node watch-or-kill.js"},
+		{PID: 2, Ports: []int{8787}, Name: "workerd", Command: "workerd serve"},
+	}})
+	m = updated.(Model)
+
+	m, _ = pressKey(t, m, "/")
+	for _, r := range "work" {
+		m, _ = pressKey(t, m, string(r))
+	}
+	if got := len(m.filteredProcesses()); got != 1 {
+		t.Fatalf("expected exact search to match 1 process, got %d", got)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = updated.(Model)
+	filtered := m.filteredProcesses()
+	if !m.fuzzy || len(filtered) != 2 || filtered[0].PID != 2 {
+		t.Errorf("expected fuzzy search to rank workerd above the scattered match, got %v", filtered)
+	}
+	if !m.searching {
+		t.Error("expected ctrl+t to keep the search bar open")
+	}
+}
+
+func TestFilteredListFollowsRefresh(t *testing.T) {
+	m := NewModel(Options{})
+	updated, _ := m.Update(refreshMsg{processes: []Process{
+		{PID: 1, Ports: []int{3000}, Name: "node", Command: "node server.js"},
+	}})
+	m = updated.(Model)
+	m, _ = pressKey(t, m, "/")
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = updated.(Model)
+	for _, r := range "work" {
+		m, _ = pressKey(t, m, string(r))
+	}
+	if got := len(m.filteredProcesses()); got != 0 {
+		t.Fatalf("expected no fuzzy match, got %d", got)
+	}
+
+	updated, _ = m.Update(refreshMsg{processes: []Process{
+		{PID: 1, Ports: []int{3000}, Name: "node", Command: "node server.js"},
+		{PID: 2, Ports: []int{8787}, Name: "workerd", Command: "workerd serve"},
+	}})
+	m = updated.(Model)
+	filtered := m.filteredProcesses()
+	if len(filtered) != 1 || filtered[0].PID != 2 {
+		t.Fatalf("expected the refreshed list to be filtered again, got %v", filtered)
+	}
+	if got := m.fuzzyPositions(filtered[0])[fuzzyName]; !slices.Equal(got, []int{0, 1, 2, 3}) {
+		t.Errorf("expected the match positions of the new row, got %v", got)
+	}
+}
+
+func TestSearchInputEditing(t *testing.T) {
+	m := NewModel(Options{})
+	updated, _ := m.Update(refreshMsg{processes: []Process{
+		{PID: 1, Ports: []int{3000}, Name: "café", Command: "café --port 3000"},
+		{PID: 2, Ports: []int{4000}, Name: "node", Command: "node api.js"},
+	}})
+	m = updated.(Model)
+
+	m, _ = pressKey(t, m, "/")
+	m, _ = pressKey(t, m, "café")
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m = updated.(Model)
+	if m.searchQuery != "caf" {
+		t.Errorf("expected backspace to delete one character, got %q", m.searchQuery)
+	}
+
+	m, _ = pressKey(t, m, "é name:node")
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+	m = updated.(Model)
+	if m.searchQuery != "café " {
+		t.Errorf("expected ctrl+w to delete the last word, got %q", m.searchQuery)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("port:3000"), Paste: true})
+	m = updated.(Model)
+	if m.searchQuery != "café port:3000" || len(m.filteredProcesses()) != 1 {
+		t.Errorf("expected pasted text to be searched, got %q", m.searchQuery)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	m = updated.(Model)
+	if m.searchQuery != "" || len(m.filteredProcesses()) != 2 {
+		t.Errorf("expected ctrl+u to clear the search, got %q", m.searchQuery)
+	}
+
+	// ctrl+b and ctrl+f move the cursor rather than toggling fuzzy search
+	m, _ = pressKey(t, m, "nde")
+	for _, k := range []tea.KeyType{tea.KeyCtrlB, tea.KeyCtrlB, tea.KeyCtrlB, tea.KeyCtrlF} {
+		updated, _ = m.Update(tea.KeyMsg{Type: k})
+		m = updated.(Model)
+	}
+	m, _ = pressKey(t, m, "o")
+	if m.searchQuery != "node" || m.fuzzy {
+		t.Errorf("expected ctrl+f to move the cursor forward, got %q (fuzzy %v)", m.searchQuery, m.fuzzy)
+	}
+}
+
+func TestSearchHistoryBrowsing(t *testing.T) {
+	m := NewModel(Options{SearchHistory: []string{"node", "port:3000"}})
+	m, _ = pressKey(t, m, "/")
+	m, _ = pressKey(t, m, "vi")
+
+	steps := []struct {
+		key  tea.KeyType
+		want string
+	}{
+		{tea.KeyUp, "port:3000"},
+		{tea.KeyUp, "node"},
+		{tea.KeyUp, "node"}, // oldest entry
+		{tea.KeyDown, "port:3000"},
+		{tea.KeyDown, "vi"}, // back to what was typed
+		{tea.KeyDown, "vi"},
+	}
+	for i, step := range steps {
+		updated, _ := m.Update(tea.KeyMsg{Type: step.key})
+		m = updated.(Model)
+		if m.searchQuery != step.want || m.searchInput.Value() != step.want {
+			t.Errorf("step %d: got search %q, expected %q", i, m.searchQuery, step.want)
+		}
+	}
+
+	// Submitting a search makes it the newest entry
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyUp})
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.searching || cmd == nil {
+		t.Fatal("expected enter to close the search bar and save the history")
+	}
+	if want := []string{"port:3000", "node"}; !slices.Equal(m.searchHistory, want) {
+		t.Errorf("expected history %v, got %v", want, m.searchHistory)
+	}
+}
+
+func TestColumnPicker(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m := NewModel(Options{Columns: []columnID{colPort, colProcess}})
+	updated, _ := m.Update(refreshMsg{
+		processes: []Process{{PID: 100, Ports: []int{3000}, Name: "vite"}},
+		table:     map[int]procInfo{100: {PID: 100, RSS: 2048}},
+	})
+	m = updated.(Model)
+
+	m, _ = pressKey(t, m, "c")
+	if !m.pickingColumns || !strings.Contains(m.View(), "portsweep (columns)") {
+		t.Fatal("expected c to open the column picker")
+	}
+
+	// Hide the port column, then show memory and move it to the front
+	m, _ = pressKey(t, m, " ")
+	for _, id := range m.columnPicker {
+		if id.ID == colMemory {
+			break
+		}
+		m, _ = pressKey(t, m, "j")
+	}
+	m, _ = pressKey(t, m, " ")
+	for m.pickerCursor > 0 {
+		m, _ = pressKey(t, m, "K")
+	}
+	if want := []columnID{colMemory, colProcess}; !slices.Equal(m.columns, want) {
+		t.Fatalf("expected columns %v, got %v", want, m.columns)
+	}
+
+	m, cmd := pressKey(t, m, "esc")
+	if m.pickingColumns || cmd == nil {
+		t.Fatal("expected esc to close the picker and save the columns")
+	}
+	cmd()
+	if got := loadColumns(); !slices.Equal(got, m.columns) {
+		t.Errorf("expected the columns to be saved, got %v", got)
+	}
+
+	view := m.View()
+	if !strings.Contains(view, "MEM") || strings.Contains(view, "PORT") || !strings.Contains(view, "2.0M") {
+		t.Errorf("expected the memory column instead of the port column:\n%s", view)
+	}
+	// Port is the sort column but hidden, so the header says so
+	if !strings.Contains(view, "(by port ▲)") {
+		t.Errorf("expected the hidden sort column to be named in the header:\n%s", view)
+	}
+}
+
+func TestColumnPickerKeepsOneColumn(t *testing.T) {
+	m := NewModel(Options{Columns: []columnID{colPort}})
+	m, _ = pressKey(t, m, "c")
+	m, _ = pressKey(t, m, " ")
+	if !slices.Equal(m.columns, []columnID{colPort}) {
+		t.Errorf("expected the last column to stay shown, got %v", m.columns)
+	}
+}
+
+func TestRowsFitTerminalWidth(t *testing.T) {
+	m := NewModel(Options{Columns: []columnID{colPort, colPID, colProcess, colUser, colCwd}})
+	updated, _ := m.Update(refreshMsg{
+		processes: []Process{{PID: 100, Ports: []int{3000}, Name: "ノード", User: "alice"}},
+		extras:    map[int]processExtras{100: {Cwd: "/srv/a-rather-long-project-name/packages/server", Conns: -1}},
+	})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 50, Height: 20})
+	m = updated.(Model)
+
+	lines := strings.Split(m.View(), "\n")
+	for _, line := range lines[2:4] { // header and row
+		if w := lipgloss.Width(line); w > 50 {
+			t.Errorf("expected at most 50 cells, got %d: %q", w, line)
+		}
+	}
+	if !strings.Contains(lines[3], "ノード") || !strings.Contains(lines[3], "/srv/a-rather") || !strings.Contains(lines[3], "…") {
+		t.Errorf("expected the wide name in full and the directory truncated, got %q", lines[3])
+	}
+}
+
+func TestDetailPaneRefresh(t *testing.T) {
+	processes := []Process{{PID: 100, Ports: []int{3000}, Name: "vite", Command: "node vite"}}
+	m := NewModel(Options{})
+	updated, _ := m.Update(refreshMsg{processes: processes})
+	m = updated.(Model)
+	m, _ = pressKey(t, m, "i")
+	updated, _ = m.Update(detailMsg{detail: processDetail{PID: 100, Cwd: "/srv/web"}})
+	m = updated.(Model)
+	read := m.detailRead
+
+	// Resource usage is taken from the refreshed table without reading the details again
+	updated, _ = m.Update(refreshMsg{processes: processes, table: map[int]procInfo{100: {PID: 100, RSS: 2048}}})
+	m = updated.(Model)
+	if m.detail.Info.RSS != 2048 || m.detail.Cwd != "/srv/web" || !m.detailRead.Equal(read) {
+		t.Errorf("expected the refresh to update memory only, got %+v read at %v", m.detail, m.detailRead)
+	}
+
+	// The rest is read again once it is old enough
+	m.detailRead = time.Now().Add(-DetailRereadInterval)
+	updated, _ = m.Update(refreshMsg{processes: processes, table: map[int]procInfo{100: {PID: 100}}})
+	m = updated.(Model)
+	if time.Since(m.detailRead) >= DetailRereadInterval || m.detailFor != 100 {
+		t.Errorf("expected old details to be read again, last read at %v", m.detailRead)
+	}
+}
+
+func TestDetailPane(t *testing.T) {
+	m := NewModel(Options{})
+	updated, _ := m.Update(refreshMsg{processes: []Process{
+		{PID: 100, Ports: []int{3000}, Name: "vite", Command: "node vite"},
+		{PID: 200, Ports: []int{4000}, Name: "api", Command: "node api.js"},
+	}})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 140, Height: 30})
+	m = updated.(Model)
+
+	m, cmd := pressKey(t, m, "i")
+	if m.detailPlacement() != detailRight || cmd == nil || m.detailFor != 100 {
+		t.Fatalf("expected i to open the pane beside the list and read the focused process, got placement %d", m.detailPlacement())
+	}
+
+	// Details arriving after the focus moved on are dropped
+	m, cmd = pressKey(t, m, "j")
+	if cmd == nil || m.detailFor != 200 {
+		t.Fatal("expected moving the cursor to read the newly focused process")
+	}
+	updated, _ = m.Update(detailMsg{detail: processDetail{PID: 100, Cwd: "/srv/web"}})
+	m = updated.(Model)
+	updated, _ = m.Update(detailMsg{detail: processDetail{PID: 200, Cwd: "/srv/api", Env: maskEnv([]string{"API_TOKEN=abc123"})}})
+	m = updated.(Model)
+
+	view := m.View()
+	if !strings.Contains(view, "/srv/api") || strings.Contains(view, "/srv/web") {
+		t.Errorf("expected the details of the focused process only:\n%s", view)
+	}
+	if strings.Contains(view, "abc123") || !strings.Contains(view, "API_TOKEN=••••••") {
+		t.Errorf("expected the token to be masked:\n%s", view)
+	}
+	if strings.Contains(view, "> node api.js") {
+		t.Error("expected the pane to replace the full command line")
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if w := lipgloss.Width(line); strings.Contains(line, "│") && w > 140 {
+			t.Errorf("expected the list and pane to fit 140 columns, got %d: %q", w, line)
+		}
+	}
+
+	// Narrow terminals get the pane below the list
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	m = updated.(Model)
+	if m.detailPlacement() != detailBottom {
+		t.Errorf("expected the pane below the list on a narrow terminal")
+	}
+	if got := renderedHeight(m.View(), m.width); got > m.height {
+		t.Errorf("expected the view to fit %d lines, got %d", m.height, got)
+	}
+
+	// The pane scrolls on its own
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m = updated.(Model)
+	m, _ = pressKey(t, m, "]")
+	if m.detailOffset != 1 || m.cursor != 1 {
+		t.Errorf("expected ] to scroll the pane and keep the cursor, got offset %d and cursor %d", m.detailOffset, m.cursor)
+	}
+	m, _ = pressKey(t, m, "[")
+	m, _ = pressKey(t, m, "[")
+	if m.detailOffset != 0 {
+		t.Errorf("expected [ to scroll back to the top, got offset %d", m.detailOffset)
+	}
+}
+
+func TestListenerView(t *testing.T) {
+	m := NewModel(Options{DryRun: true})
+	updated, _ := m.Update(refreshMsg{processes: []Process{
+		{PID: 100, Name: "node", Ports: []int{3000, 3001, 3002}},
+		{PID: 200, Name: "api", Ports: []int{4000}},
+	}})
+	m = updated.(Model)
+	m, _ = pressKey(t, m, "j")
+	m, _ = pressKey(t, m, " ")
+
+	// Switching keeps the focused process and selects all rows of selected ones
+	m, _ = pressKey(t, m, "v")
+	filtered := m.filteredProcesses()
+	if len(filtered) != 4 || filtered[m.cursor].PID != 200 {
+		t.Fatalf("expected 4 rows with the cursor on PID 200, got %d rows and cursor %d", len(filtered), m.cursor)
+	}
+	if m.selectedCount() != 1 || !strings.Contains(m.View(), "per listener") {
+		t.Errorf("expected the selection to carry over, got %d selected", m.selectedCount())
+	}
+
+	// Listeners are selected and searched one by one
+	m, _ = pressKey(t, m, " ")
+	m, _ = pressKey(t, m, "g")
+	m, _ = pressKey(t, m, "j")
+	m, _ = pressKey(t, m, " ")
+	if got := m.getSelectedProcesses(); len(got) != 1 || got[0].PID != 100 || m.selectedCount() != 1 {
+		t.Fatalf("expected only the listener on 3001 to be selected, got %v", got)
+	}
+	m.setSearch("port:3002")
+	if filtered := m.filteredProcesses(); len(filtered) != 1 || filtered[0].Ports[0] != 3002 {
+		t.Errorf("expected the search to match a single listener, got %v", filtered)
+	}
+	m.setSearch("")
+
+	// Killing picks the whole process once and says so
+	m, _ = pressKey(t, m, "a")
+	m, _ = pressKey(t, m, "a")
+	m, _ = pressKey(t, m, "g")
+	m, _ = pressKey(t, m, " ")
+	m, _ = pressKey(t, m, "j")
+	m, _ = pressKey(t, m, " ")
+	m, _ = pressKey(t, m, "d")
+	if len(m.toKill) != 1 || m.toKill[0].PID != 100 || len(m.toKill[0].Ports) != 3 {
+		t.Fatalf("expected to kill process 100 with all its ports once, got %v", m.toKill)
+	}
+	if note := m.killScopeNote(); note != "Killing affects the whole process, closing all 3 of its listeners" {
+		t.Errorf("unexpected note %q", note)
+	}
+	if !strings.Contains(m.View(), "closing all 3 of its listeners") {
+		t.Error("expected the note in the confirmation")
+	}
+
+	// The kill deselects every row of the process
+	m, _ = pressKey(t, m, "y")
+	updated, _ = m.Update(killResultMsg{pid: 100, port: 3000, success: true, dryRun: true})
+	m = updated.(Model)
+	if m.selectedCount() != 0 {
+		t.Errorf("expected no selection left, got %d", m.selectedCount())
+	}
+
+	// Back to one row per process
+	m, _ = pressKey(t, m, "v")
+	if got := len(m.filteredProcesses()); got != 2 {
+		t.Errorf("expected 2 rows per process, got %d", got)
+	}
+}
+
+func TestKillScopeNoteForSeveralProcesses(t *testing.T) {
+	m := NewModel(Options{})
+	m.perListener = true
+	m.processes = []Process{
+		{PID: 100, Ports: []int{3000, 3001}},
+		{PID: 200, Ports: []int{4000, 4001}},
+	}
+	rows := m.rows()
+	for _, r := range []Process{rows[0], rows[2]} {
+		m.selected[m.rowKey(r)] = true
+	}
+	m.toKill = m.getSelectedProcesses()
+	want := "The 2 selected listeners belong to 2 processes; killing them closes all 4 of their listeners"
+	if got := m.killScopeNote(); got != want {
+		t.Errorf("killScopeNote() = %q, expected %q", got, want)
+	}
+
+	// Nothing is worth noting when every listener was picked
+	for _, r := range rows {
+		m.selected[m.rowKey(r)] = true
+	}
+	if got := m.killScopeNote(); got != "" {
+		t.Errorf("expected no note, got %q", got)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// isTerminal reports whether f is connected to a terminal. Other character
+// devices, such as /dev/null, are not terminals.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(f.Fd())
+}
+
+// plainProcesses returns the processes plain output lists for a filter.
+// Without a filter it shows what the TUI shows by default (user ports only);
+// with one it matches against all ports, so `portsweep 80 | cat` works.
+func plainProcesses(processes []Process, filter *query) []Process {
+	var result []Process
+	for _, p := range processes {
+		if filter != nil {
+			if !filter.matches(p) {
+				continue
+			}
+		} else if !hasUserPort(p) {
+			continue
+		}
+		result = append(result, p)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LowestPort() < result[j].LowestPort()
+	})
+	return result
+}
+
+// hasUserPort reports whether a process listens on at least one non-system port
+func hasUserPort(p Process) bool {
+	for _, port := range p.Ports {
+		if port >= SystemPortThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// printPlainTable writes processes as a tab-aligned plain-text table
+func printPlainTable(w io.Writer, processes []Process) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PORT\tPID\tPROCESS\tUSER\tCOMMAND")
+	for _, p := range processes {
+		ports := make([]string, len(p.Ports))
+		for i, port := range p.Ports {
+			ports[i] = strconv.Itoa(port)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n",
+			strings.Join(ports, ","), p.PID, p.Name, p.User, formatCommand(p.Command))
+	}
+	return tw.Flush()
+}
+
+// runPlain prints the filtered process list without the TUI. It returns 0
+// when at least one process matched and 1 otherwise, like grep.
+func runPlain(filter string) int {
+	q, err := parseQuery(filter)
+	if err != nil {
+		eprintf("invalid filter %q: %v", filter, err)
+		return 2
+	}
+	processes, err := GetListeningPorts()
+	if err != nil {
+		eprintf("scanning ports: %v", err)
+		return 2
+	}
+
+	matched := plainProcesses(processes, q)
+	if len(matched) == 0 {
+		return 1
+	}
+	if err := printPlainTable(os.Stdout, matched); err != nil {
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPlainProcesses(t *testing.T) {
+	processes := []Process{
+		{PID: 3, Ports: []int{8080}, Name: "nginx", Command: "nginx: worker"},
+		{PID: 1, Ports: []int{22}, Name: "sshd", Command: "/usr/sbin/sshd -D"},
+		{PID: 2, Ports: []int{3000}, Name: "node", Command: "node server.js"},
+	}
+
+	tests := []struct {
+		filter   string
+		expected []int
+	}{
+		{"", []int{2, 3}},  // user ports only, sorted by port
+		{"22", []int{1}},   // explicit port matches system ports too
+		{"NODE", []int{2}}, // case-insensitive name match
+		{"worker", []int{3}},
+		{"300", nil}, // numeric filters are exact port matches
+		{"nothing", nil},
+	}
+
+	for _, tt := range tests {
+		q, err := parseQuery(tt.filter)
+		if err != nil {
+			t.Fatalf("parseQuery(%q): %v", tt.filter, err)
+		}
+		got := plainProcesses(processes, q)
+		var pids []int
+		for _, p := range got {
+			pids = append(pids, p.PID)
+		}
+		if len(pids) != len(tt.expected) {
+			t.Errorf("plainProcesses(%q) = %v, expected %v", tt.filter, pids, tt.expected)
+			continue
+		}
+		for i := range pids {
+			if pids[i] != tt.expected[i] {
+				t.Errorf("plainProcesses(%q) = %v, expected %v", tt.filter, pids, tt.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestPrintPlainTable(t *testing.T) {
+	var out strings.Builder
+	err := printPlainTable(&out, []Process{
+		{PID: 123, Ports: []int{3000, 3001}, Name: "node", User: "me", Command: "node /Users/me/Code/web/node_modules/.bin/vite"},
+		{PID: 45, Ports: []int{8080}, Name: "nginx", User: "www", Command: "/usr/sbin/nginx"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `PORT       PID  PROCESS  USER  COMMAND
+3000,3001  123  node     me    vite (web)
+8080       45   nginx    www   nginx
+`
+	if out.String() != expected {
+		t.Errorf("unexpected table:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestIsTerminalRejectsDevNull(t *testing.T) {
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Error("expected /dev/null not to count as a terminal")
+	}
+}
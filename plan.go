@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"slices"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// planVersion is the current plan file format version
+const planVersion = 1
+
+// killPlan is a reviewed list of processes to signal, written by `portsweep plan`
+// and executed by `portsweep apply`
+type killPlan struct {
+	Version   int          `json:"version"`
+	CreatedAt time.Time    `json:"created_at"`
+	CreatedBy string       `json:"created_by"`
+	Host      string       `json:"host"`
+	Targets   []planTarget `json:"targets"`
+}
+
+// planTarget identifies a single process in a plan. PID and start time
+// together identify the process even if the PID is reused later.
+type planTarget struct {
+	PID              int       `json:"pid"`
+	StartTime        time.Time `json:"start_time"`
+	Ports            []int     `json:"ports"`
+	Name             string    `json:"name"`
+	User             string    `json:"user"`
+	Command          string    `json:"command"`
+	FormattedCommand string    `json:"formatted_command"`
+	Signal           string    `json:"signal"`
+}
+
+// applyResult is the outcome of applying a plan to a single target
+type applyResult struct {
+	Target  planTarget
+	Skipped error // why the target was not signalled because it changed, if it was not
+	Err     error // why signalling failed, if it did
+}
+
+// planOptions holds the flags for the plan subcommand
+type planOptions struct {
+	signal string
+	output string
+}
+
+// newPlanFlags returns the flag set for the plan subcommand bound to opts
+func newPlanFlags(opts *planOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	fs.StringVar(&opts.signal, "signal", "TERM", "signal to send to each target")
+	fs.StringVar(&opts.output, "o", "-", "file to write the plan to (- for stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: portsweep plan [--signal TERM] [-o plan.json] <port|name>...")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Write a JSON plan listing every process matching one of the filters, to be reviewed")
+		fmt.Fprintln(fs.Output(), "and then executed with `portsweep apply`.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runPlan implements `portsweep plan`
+func runPlan(args []string) int {
+	opts := planOptions{}
+	fs := newPlanFlags(&opts)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	filters := fs.Args()
+	if len(filters) == 0 {
+		fs.Usage()
+		return 2
+	}
+	sig, err := parseSignal(opts.signal)
+	if err != nil {
+		eprintf("%v", err)
+		return 2
+	}
+	q, err := parseAnyQuery(filters)
+	if err != nil {
+		eprintf("%v", err)
+		return 2
+	}
+
+	processes, err := defaultScanner.GetListeningPorts()
+	if err != nil {
+		eprintf("scanning ports: %v", err)
+		return 1
+	}
+	table, err := readProcessTable()
+	if err != nil {
+		eprintf("reading process table: %v", err)
+		return 1
+	}
+
+	plan := newKillPlan(processes, table, q, sig)
+	if len(plan.Targets) == 0 {
+		eprintf("no processes match")
+		return 1
+	}
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		eprintf("%v", err)
+		return 1
+	}
+	data = append(data, '\n')
+	if opts.output == "-" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	if err := os.WriteFile(opts.output, data, 0o644); err != nil {
+		eprintf("%v", err)
+		return 1
+	}
+	eprintf("wrote plan for %s to %s", pluralize(len(plan.Targets), "process", "processes"), opts.output)
+	return 0
+}
+
+// newKillPlan builds a plan targeting every process that matches the filter
+func newKillPlan(processes []Process, table map[int]procInfo, filter *query, sig syscall.Signal) killPlan {
+	host, _ := os.Hostname()
+	plan := killPlan{
+		Version:   planVersion,
+		CreatedAt: time.Now(),
+		CreatedBy: currentUsername(),
+		Host:      host,
+		Targets:   []planTarget{},
+	}
+
+	for _, p := range processes {
+		if !filter.matches(p) {
+			continue
+		}
+		plan.Targets = append(plan.Targets, planTarget{
+			PID:              p.PID,
+			StartTime:        table[p.PID].StartTime,
+			Ports:            p.Ports,
+			Name:             p.Name,
+			User:             p.User,
+			Command:          p.Command,
+			FormattedCommand: formatCommand(p.Command),
+			Signal:           signalName(sig),
+		})
+	}
+
+	sort.Slice(plan.Targets, func(i, j int) bool {
+		return plan.Targets[i].Ports[0] < plan.Targets[j].Ports[0]
+	})
+	return plan
+}
+
+// currentUsername returns the name of the user running portsweep
+func currentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// applyOptions holds the flags for the apply subcommand
+type applyOptions struct {
+	force  bool
+	dryRun bool
+}
+
+// newApplyFlags returns the flag set for the apply subcommand bound to opts
+func newApplyFlags(opts *applyOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	fs.BoolVar(&opts.force, "force", false, "apply a plan created on another host")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "verify the targets without signalling them")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: portsweep apply [--force] [--dry-run] <plan.json>")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Signal the targets of a plan written by `portsweep plan`. Every target is verified")
+		fmt.Fprintln(fs.Output(), "first; targets whose process, start time, command or ports changed are skipped.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runApply implements `portsweep apply`
+func runApply(args []string) int {
+	opts := applyOptions{}
+	fs := newApplyFlags(&opts)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	plan, err := readKillPlan(fs.Arg(0))
+	if err != nil {
+		eprintf("%v", err)
+		return 1
+	}
+	if host, _ := os.Hostname(); plan.Host != host && !opts.force {
+		eprintf("plan was created on %s, not %s (use --force to apply anyway)", plan.Host, host)
+		return 1
+	}
+
+	processes, err := defaultScanner.GetListeningPorts()
+	if err != nil {
+		eprintf("scanning ports: %v", err)
+		return 1
+	}
+	table, err := readProcessTable()
+	if err != nil {
+		eprintf("reading process table: %v", err)
+		return 1
+	}
+
+	recorder := &RecordingKiller{}
+	results := applyPlan(plan, processes, table, func(sig syscall.Signal) ProcessKiller {
+		if opts.dryRun {
+			return recorder
+		}
+		return auditedKiller(&SignalKiller{Signal: sig}, "apply")
+	})
+	printApplyResults(os.Stdout, results, opts.dryRun)
+
+	for _, r := range results {
+		if r.Skipped != nil || r.Err != nil {
+			return 1
+		}
+	}
+	return 0
+}
+
+// readKillPlan loads and validates a plan file
+func readKillPlan(name string) (killPlan, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return killPlan{}, err
+	}
+	var plan killPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return killPlan{}, fmt.Errorf("%s: not a portsweep plan: %w", name, err)
+	}
+	if plan.Version != planVersion {
+		return killPlan{}, fmt.Errorf("%s: unsupported plan version %d", name, plan.Version)
+	}
+	for _, t := range plan.Targets {
+		if _, err := parseSignal(t.Signal); err != nil {
+			return killPlan{}, fmt.Errorf("%s: pid %d: %w", name, t.PID, err)
+		}
+	}
+	return plan, nil
+}
+
+// applyPlan verifies every target against the current processes and signals
+// the ones that are unchanged, using the killer returned by killerFor.
+func applyPlan(plan killPlan, processes []Process, table map[int]procInfo, killerFor func(sig syscall.Signal) ProcessKiller) []applyResult {
+	current := make(map[int]Process)
+	for _, p := range processes {
+		current[p.PID] = p
+	}
+
+	results := make([]applyResult, 0, len(plan.Targets))
+	for _, t := range plan.Targets {
+		result := applyResult{Target: t}
+		if err := verifyTarget(t, current, table); err != nil {
+			result.Skipped = err
+		} else {
+			sig, _ := parseSignal(t.Signal) // validated by readKillPlan
+			result.Err = sendKill(killerFor(sig), killTarget{Process: current[t.PID], StartTime: table[t.PID].StartTime})
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// verifyTarget checks that a target is still the same process, holding the same ports
+func verifyTarget(t planTarget, current map[int]Process, table map[int]procInfo) error {
+	info, ok := table[t.PID]
+	if !ok {
+		return errors.New("process has exited")
+	}
+	if t.StartTime.IsZero() || !info.StartTime.Equal(t.StartTime) {
+		return fmt.Errorf("pid was reused (process started %s, plan expected %s)",
+			info.StartTime.Format(time.DateTime), t.StartTime.Format(time.DateTime))
+	}
+
+	p, ok := current[t.PID]
+	if !ok {
+		return errors.New("process is no longer listening")
+	}
+	if p.Command != t.Command {
+		return fmt.Errorf("command changed to %q", p.Command)
+	}
+	for _, port := range t.Ports {
+		if !slices.Contains(p.Ports, port) {
+			return fmt.Errorf("no longer listening on port %d", port)
+		}
+	}
+	return nil
+}
+
+// printApplyResults writes one line per target with the outcome of applying the plan
+func printApplyResults(w io.Writer, results []applyResult, dryRun bool) {
+	for _, r := range results {
+		outcome := "sent " + r.Target.Signal
+		if dryRun {
+			outcome = "would send " + r.Target.Signal + " (dry run)"
+		}
+		switch {
+		case r.Skipped != nil:
+			outcome = "skipped: " + r.Skipped.Error()
+		case r.Err != nil:
+			outcome = "failed: " + r.Err.Error()
+		}
+		label := r.Target.FormattedCommand
+		if label == "" {
+			label = r.Target.Name
+		}
+		fmt.Fprintf(w, "%-18s pid %-8d %-30s %s\n", formatPorts(r.Target.Ports, 18), r.Target.PID, label, outcome)
+	}
+}
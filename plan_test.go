@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNewKillPlan(t *testing.T) {
+	started := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+	processes := []Process{
+		{PID: 3, Ports: []int{8080}, Name: "nginx", Command: "nginx: worker"},
+		{PID: 1, Ports: []int{3000}, Name: "node", Command: "node /Users/me/Code/web/node_modules/.bin/vite"},
+		{PID: 2, Ports: []int{5432}, Name: "postgres", Command: "/usr/bin/postgres"},
+	}
+	table := map[int]procInfo{1: {PID: 1, StartTime: started}, 3: {PID: 3, StartTime: started}}
+
+	filter, err := parseAnyQuery([]string{"nginx", "3000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := newKillPlan(processes, table, filter, syscall.SIGINT)
+
+	if len(plan.Targets) != 2 {
+		t.Fatalf("expected 2 targets, got %+v", plan.Targets)
+	}
+	first := plan.Targets[0]
+	if first.PID != 1 || first.Signal != "SIGINT" || !first.StartTime.Equal(started) || first.FormattedCommand != "vite (web)" {
+		t.Errorf("unexpected first target: %+v", first)
+	}
+	if plan.Targets[1].PID != 3 {
+		t.Errorf("expected targets sorted by port, got %+v", plan.Targets)
+	}
+}
+
+func TestApplyPlan(t *testing.T) {
+	started := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+	target := func(pid int, ports ...int) planTarget {
+		return planTarget{PID: pid, StartTime: started, Ports: ports, Command: "server", Signal: "SIGTERM"}
+	}
+	plan := killPlan{Targets: []planTarget{
+		target(1, 3000),       // unchanged
+		target(2, 4000),       // exited
+		target(3, 5000),       // PID reused by a newer process
+		target(4, 6000),       // command changed
+		target(5, 7000, 7001), // stopped listening on one port
+		target(6, 8000),       // unchanged, but the kill fails
+	}}
+	processes := []Process{
+		{PID: 1, Ports: []int{3000}, Command: "server"},
+		{PID: 3, Ports: []int{5000}, Command: "server"},
+		{PID: 4, Ports: []int{6000}, Command: "other"},
+		{PID: 5, Ports: []int{7000}, Command: "server"},
+		{PID: 6, Ports: []int{8000}, Command: "server"},
+	}
+	table := map[int]procInfo{
+		1: {PID: 1, StartTime: started},
+		3: {PID: 3, StartTime: started.Add(time.Hour)},
+		4: {PID: 4, StartTime: started},
+		5: {PID: 5, StartTime: started},
+		6: {PID: 6, StartTime: started},
+	}
+
+	killer := &MockKiller{}
+	failing := &MockKiller{Err: errors.New("operation not permitted")}
+	results := applyPlan(plan, processes, table, func(sig syscall.Signal) ProcessKiller {
+		if sig != syscall.SIGTERM {
+			t.Errorf("expected SIGTERM, got %v", sig)
+		}
+		if len(killer.KilledPIDs) == 1 {
+			return failing
+		}
+		return killer
+	})
+
+	if len(killer.KilledPIDs) != 1 || killer.KilledPIDs[0] != 1 {
+		t.Errorf("expected only PID 1 to be killed, got %v", killer.KilledPIDs)
+	}
+	for i, r := range results {
+		switch r.Target.PID {
+		case 1:
+			if r.Skipped != nil || r.Err != nil {
+				t.Errorf("result[%d]: expected success, got %+v", i, r)
+			}
+		case 6:
+			if r.Skipped != nil || r.Err == nil {
+				t.Errorf("result[%d]: expected kill error, got %+v", i, r)
+			}
+		default:
+			if r.Skipped == nil {
+				t.Errorf("result[%d]: expected PID %d to be skipped", i, r.Target.PID)
+			}
+		}
+	}
+}
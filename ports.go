@@ -2,20 +2,32 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"os/exec"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
 // Process represents a process listening on one or more ports
 type Process struct {
-	PID     int
-	Ports   []int
-	Name    string
-	User    string
-	Command string
+	PID       int        `json:"pid"`
+	Ports     []int      `json:"ports"`
+	Name      string     `json:"name"`
+	User      string     `json:"user"`
+	Command   string     `json:"command"`
+	Listeners []Listener `json:"listeners"` // every listening socket, including duplicates of a port on other interfaces
+}
+
+// Listener is a single listening socket of a process
+type Listener struct {
+	Address  string `json:"address"`  // bind address, "*" for all interfaces
+	Port     int    `json:"port"`     // TCP port number
+	Protocol string `json:"protocol"` // "tcp4" or "tcp6"
 }
 
 // LowestPort returns the lowest port number for this process.
@@ -37,6 +49,34 @@ type ProcessKiller interface {
 	Kill(pid int) error
 }
 
+// killerFunc adapts a function to the ProcessKiller interface
+type killerFunc func(pid int) error
+
+// Kill calls f(pid)
+func (f killerFunc) Kill(pid int) error {
+	return f(pid)
+}
+
+// killTarget is a process to kill, with what the caller already knows about it
+type killTarget struct {
+	Process
+	StartTime time.Time // zero if unknown
+}
+
+// targetKiller is implemented by killers that use the details of the process
+// they kill; passing them along saves looking the process up again
+type targetKiller interface {
+	KillTarget(t killTarget) error
+}
+
+// sendKill kills a process, passing its details on to killers that use them
+func sendKill(killer ProcessKiller, t killTarget) error {
+	if k, ok := killer.(targetKiller); ok {
+		return k.KillTarget(t)
+	}
+	return killer.Kill(t.PID)
+}
+
 // LsofScanner implements PortScanner using the lsof command
 type LsofScanner struct {
 	// CommandLookup is used to get full command lines for PIDs.
@@ -69,6 +109,26 @@ func (s *LsofScanner) GetListeningPorts() ([]Process, error) {
 	return parseLsofOutput(string(output), lookup)
 }
 
+// lookupListener returns the listening ports and command of a single process.
+// The process has no ports if it is not listening.
+func lookupListener(pid int) (Process, error) {
+	cmd := exec.Command("lsof", "-a", "-p", strconv.Itoa(pid), "-iTCP", "-sTCP:LISTEN", "-n", "-P")
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return Process{PID: pid, Command: getFullCommand(pid)}, nil
+		}
+		return Process{}, err
+	}
+
+	processes, err := parseLsofOutput(string(output), getFullCommand)
+	if err != nil || len(processes) == 0 {
+		return Process{PID: pid, Command: getFullCommand(pid)}, err
+	}
+	return processes[0], nil
+}
+
 // SignalKiller implements ProcessKiller using syscall.Kill
 type SignalKiller struct {
 	Signal syscall.Signal
@@ -83,6 +143,66 @@ func (k *SignalKiller) Kill(pid int) error {
 	return syscall.Kill(pid, sig)
 }
 
+// RecordingKiller implements ProcessKiller without sending any signal.
+// It records the PIDs it was asked to kill, for dry runs.
+type RecordingKiller struct {
+	Rehearsed ProcessKiller // the killer a dry run stands in for, to describe its kills; may be nil
+
+	mu     sync.Mutex
+	killed []int
+}
+
+// Kill records the PID instead of signalling it
+func (k *RecordingKiller) Kill(pid int) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.killed = append(k.killed, pid)
+	return nil
+}
+
+// Killed returns the PIDs recorded so far, in order
+func (k *RecordingKiller) Killed() []int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return slices.Clone(k.killed)
+}
+
+// signalNames maps the signals portsweep sends to their conventional names
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGTERM: "SIGTERM",
+	syscall.SIGUSR1: "SIGUSR1",
+	syscall.SIGUSR2: "SIGUSR2",
+}
+
+// signalName returns the conventional name of a signal, such as "SIGTERM"
+func signalName(sig syscall.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return "signal " + strconv.Itoa(int(sig))
+}
+
+// parseSignal parses a signal given as "TERM", "SIGTERM" (any case) or a number such as "15"
+func parseSignal(s string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return syscall.Signal(n), nil
+	}
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	for sig, sigName := range signalNames {
+		if sigName == name {
+			return sig, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signal %q", s)
+}
+
 // Default implementations used by the application
 var (
 	defaultScanner = &LsofScanner{}
@@ -126,6 +246,7 @@ func parseLsofOutput(output string, commandLookup func(pid int) string) ([]Proce
 		name := fields[0]
 		pidStr := fields[1]
 		user := fields[2]
+		ipType := fields[4]
 		nameField := fields[len(fields)-1]
 
 		// Handle "(LISTEN)" suffix
@@ -144,8 +265,18 @@ func parseLsofOutput(output string, commandLookup func(pid int) string) ([]Proce
 			continue
 		}
 
+		listener := Listener{
+			Address:  parseAddress(nameField),
+			Port:     port,
+			Protocol: parseProtocol(ipType),
+		}
+
 		// Skip if we've already seen this port (can have multiple entries for same port on different interfaces)
 		if seenPorts[port] {
+			// Still record the extra interface if it belongs to the same process
+			if proc, exists := processMap[pid]; exists && slices.Contains(proc.Ports, port) && !slices.Contains(proc.Listeners, listener) {
+				proc.Listeners = append(proc.Listeners, listener)
+			}
 			continue
 		}
 		seenPorts[port] = true
@@ -153,6 +284,7 @@ func parseLsofOutput(output string, commandLookup func(pid int) string) ([]Proce
 		// Add to existing process or create new one
 		if proc, exists := processMap[pid]; exists {
 			proc.Ports = append(proc.Ports, port)
+			proc.Listeners = append(proc.Listeners, listener)
 		} else {
 			// Get full command line (only once per PID)
 			command := ""
@@ -160,11 +292,12 @@ func parseLsofOutput(output string, commandLookup func(pid int) string) ([]Proce
 				command = commandLookup(pid)
 			}
 			processMap[pid] = &Process{
-				PID:     pid,
-				Ports:   []int{port},
-				Name:    name,
-				User:    user,
-				Command: command,
+				PID:       pid,
+				Ports:     []int{port},
+				Name:      name,
+				User:      user,
+				Command:   command,
+				Listeners: []Listener{listener},
 			}
 		}
 	}
@@ -174,6 +307,13 @@ func parseLsofOutput(output string, commandLookup func(pid int) string) ([]Proce
 	for _, proc := range processMap {
 		// Sort ports ascending
 		sort.Ints(proc.Ports)
+		sort.Slice(proc.Listeners, func(i, j int) bool {
+			a, b := proc.Listeners[i], proc.Listeners[j]
+			if a.Port != b.Port {
+				return a.Port < b.Port
+			}
+			return a.Address < b.Address
+		})
 		processes = append(processes, *proc)
 	}
 
@@ -197,6 +337,24 @@ func parsePort(nameField string) int {
 	return port
 }
 
+// parseAddress extracts the bind address from a lsof NAME field.
+// Handles formats like "*:3000" -> "*", "127.0.0.1:8080" -> "127.0.0.1", "[::1]:3000" -> "::1".
+func parseAddress(nameField string) string {
+	idx := strings.LastIndex(nameField, ":")
+	if idx == -1 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(nameField[:idx], "["), "]")
+}
+
+// parseProtocol converts a lsof TYPE field ("IPv4" or "IPv6") into a protocol name
+func parseProtocol(ipType string) string {
+	if ipType == "IPv6" {
+		return "tcp6"
+	}
+	return "tcp4"
+}
+
 // getFullCommand gets the full command line for a PID using ps
 func getFullCommand(pid int) string {
 	cmd := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "command=")
@@ -207,3 +365,50 @@ func getFullCommand(pid int) string {
 
 	return strings.TrimSpace(string(output))
 }
+
+// countConnections returns how many established TCP connections each process
+// has accepted on its listening ports, by PID
+func countConnections(processes []Process) (map[int]int, error) {
+	output, err := exec.Command("lsof", "-iTCP", "-sTCP:ESTABLISHED", "-n", "-P").Output()
+	if err != nil {
+		// lsof returns exit code 1 if there are no connections
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return map[int]int{}, nil
+		}
+		return nil, err
+	}
+	return parseConnectionCounts(string(output), processes), nil
+}
+
+// parseConnectionCounts counts the connections in lsof output whose local
+// port is one of the listening ports of the process owning them. Outgoing
+// connections of the same process use other ports and are not counted.
+func parseConnectionCounts(output string, processes []Process) map[int]int {
+	listening := make(map[int][]int, len(processes))
+	for _, p := range processes {
+		listening[p.PID] = p.Ports
+	}
+
+	counts := make(map[int]int, len(processes))
+	for i, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) < 9 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		// NAME looks like "127.0.0.1:3000->127.0.0.1:52144 (ESTABLISHED)"
+		name := fields[len(fields)-1]
+		if strings.HasPrefix(name, "(") {
+			name = fields[len(fields)-2]
+		}
+		local, _, ok := strings.Cut(name, "->")
+		if ok && slices.Contains(listening[pid], parsePort(local)) {
+			counts[pid]++
+		}
+	}
+	return counts
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"reflect"
+	"slices"
+	"syscall"
 	"testing"
 )
 
@@ -183,6 +186,56 @@ func TestParsePort(t *testing.T) {
 	}
 }
 
+func TestParseLsofListeners(t *testing.T) {
+	input := `COMMAND   PID   USER   FD   TYPE     DEVICE SIZE/OFF NODE NAME
+node      123   user   22u  IPv4 0x123456      0t0  TCP *:3000 (LISTEN)
+node      123   user   23u  IPv6 0x123457      0t0  TCP [::]:3000 (LISTEN)
+node      123   user   24u  IPv4 0x123458      0t0  TCP 127.0.0.1:3001 (LISTEN)
+other     456   user   5u   IPv6 0x789012      0t0  TCP [::1]:3000 (LISTEN)`
+
+	result, err := parseLsofOutput(input, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 1 {
+		t.Fatalf("expected 1 process, got %d", len(result))
+	}
+
+	expected := []Listener{
+		{Address: "*", Port: 3000, Protocol: "tcp4"},
+		{Address: "::", Port: 3000, Protocol: "tcp6"},
+		{Address: "127.0.0.1", Port: 3001, Protocol: "tcp4"},
+	}
+	got := result[0].Listeners
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d listeners, got %v", len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("listener[%d] = %+v, expected %+v", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"*:3000", "*"},
+		{"127.0.0.1:8080", "127.0.0.1"},
+		{"[::1]:3000", "::1"},
+		{"[::]:8080", "::"},
+		{"3000", ""},
+	}
+
+	for _, tt := range tests {
+		if got := parseAddress(tt.input); got != tt.expected {
+			t.Errorf("parseAddress(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
 func TestProcessLowestPort(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -205,6 +258,34 @@ func TestProcessLowestPort(t *testing.T) {
 	}
 }
 
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{"TERM", syscall.SIGTERM, false},
+		{"SIGKILL", syscall.SIGKILL, false},
+		{"int", syscall.SIGINT, false},
+		{"sigquit", syscall.SIGQUIT, false},
+		{"9", syscall.SIGKILL, false},
+		{"BOGUS", 0, true},
+		{"", 0, true},
+		{"-1", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSignal(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSignal(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSignal(%q) = %v, expected %v", tt.input, got, tt.want)
+		}
+	}
+}
+
 // MockScanner implements PortScanner for testing
 type MockScanner struct {
 	Processes []Process
@@ -265,3 +346,37 @@ func TestMockKiller(t *testing.T) {
 		t.Errorf("expected KilledPIDs=[123], got %v", killer.KilledPIDs)
 	}
 }
+
+func TestRecordingKiller(t *testing.T) {
+	killer := &RecordingKiller{}
+
+	var _ ProcessKiller = killer // Compile-time interface check
+
+	for _, pid := range []int{123, 456} {
+		if err := killer.Kill(pid); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := killer.Killed(); !slices.Equal(got, []int{123, 456}) {
+		t.Errorf("expected Killed()=[123 456], got %v", got)
+	}
+}
+
+func TestParseConnectionCounts(t *testing.T) {
+	output := `COMMAND   PID USER   FD   TYPE DEVICE SIZE/OFF NODE NAME
+node      123 user   25u  IPv4 0x1234      0t0  TCP 127.0.0.1:3000->127.0.0.1:52144 (ESTABLISHED)
+node      123 user   26u  IPv6 0x1235      0t0  TCP [::1]:3000->[::1]:52145 (ESTABLISHED)
+node      123 user   27u  IPv4 0x1236      0t0  TCP 127.0.0.1:52146->127.0.0.1:5432 (ESTABLISHED)
+postgres  456 user   10u  IPv4 0x1237      0t0  TCP 127.0.0.1:5432->127.0.0.1:52146 (ESTABLISHED)
+curl      789 user    5u  IPv4 0x1238      0t0  TCP 127.0.0.1:52144->127.0.0.1:3000 (ESTABLISHED)
+`
+	processes := []Process{
+		{PID: 123, Ports: []int{3000}},
+		{PID: 456, Ports: []int{5432}},
+	}
+	got := parseConnectionCounts(output, processes)
+	// The outgoing connection of node to postgres and the client side of curl are not counted
+	if want := map[int]int{123: 2, 456: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseConnectionCounts() = %v, expected %v", got, want)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// psStartLayout is the format of the `ps -o lstart` column in the C locale
+const psStartLayout = "Mon Jan 2 15:04:05 2006"
+
+// procInfo holds the process table details of a single process
+type procInfo struct {
+	PID       int
+	PPID      int
+	RSS       int64   // resident memory in KiB
+	CPU       float64 // CPU usage in percent, as reported by ps
+	StartTime time.Time
+}
+
+// readProcessTable returns the process table details for every process on the system
+func readProcessTable() (map[int]procInfo, error) {
+	cmd := exec.Command("ps", "-A", "-o", "pid=,ppid=,rss=,pcpu=,lstart=")
+	// lstart is locale dependent, so force the format parsePsTable expects
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return parsePsTable(string(output)), nil
+}
+
+// parsePsTable parses `ps -o pid=,ppid=,rss=,pcpu=,lstart=` output into a PID -> procInfo map.
+// Memory, CPU and start time are optional so output with just PID and PPID columns is accepted too.
+func parsePsTable(output string) map[int]procInfo {
+	table := make(map[int]procInfo)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		ppid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+
+		info := procInfo{PID: pid, PPID: ppid}
+		if len(fields) > 2 {
+			info.RSS, _ = strconv.ParseInt(fields[2], 10, 64)
+		}
+		if len(fields) > 3 {
+			info.CPU, _ = strconv.ParseFloat(fields[3], 64)
+		}
+		if len(fields) > 4 {
+			// lstart looks like "Sat Oct 18 10:00:00 2026" with padded days
+			start, err := time.ParseInLocation(psStartLayout, strings.Join(fields[4:], " "), time.Local)
+			if err == nil {
+				info.StartTime = start
+			}
+		}
+		table[pid] = info
+	}
+	return table
+}
+
+// isDescendant reports whether pid is ancestor itself or one of its descendants
+// according to the process table.
+func isDescendant(table map[int]procInfo, pid, ancestor int) bool {
+	// Bound the walk in case the table contains a cycle
+	for i := 0; i < len(table)+1 && pid > 0; i++ {
+		if pid == ancestor {
+			return true
+		}
+		info, ok := table[pid]
+		if !ok || info.PPID == pid {
+			return false
+		}
+		pid = info.PPID
+	}
+	return false
+}
+
+// ancestorsOf returns the parent, grandparent and so on of pid, nearest first,
+// as far as the process table knows them
+func ancestorsOf(table map[int]procInfo, pid int) []int {
+	var ancestors []int
+	// Bound the walk in case the table contains a cycle
+	for i := 0; i < len(table); i++ {
+		info, ok := table[pid]
+		if !ok || info.PPID <= 0 || info.PPID == pid {
+			break
+		}
+		pid = info.PPID
+		ancestors = append(ancestors, pid)
+	}
+	return ancestors
+}
+
+// descendantsOf returns the PIDs of ancestor and all of its descendants
+func descendantsOf(table map[int]procInfo, ancestor int) []int {
+	var pids []int
+	for pid := range table {
+		if isDescendant(table, pid, ancestor) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// processStartTime returns when a single process started
+func processStartTime(pid int) (time.Time, error) {
+	cmd := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "lstart=")
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	output, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation(psStartLayout, strings.Join(strings.Fields(string(output)), " "), time.Local)
+}
+
+// processCwd returns the working directory of a process. It reads /proc where
+// available and falls back to lsof elsewhere.
+func processCwd(pid int) (string, error) {
+	if cwd, err := os.Readlink("/proc/" + strconv.Itoa(pid) + "/cwd"); err == nil {
+		return cwd, nil
+	}
+
+	// -Fn prints the path on a line starting with "n"
+	output, err := exec.Command("lsof", "-a", "-p", strconv.Itoa(pid), "-d", "cwd", "-Fn").Output()
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if path, ok := strings.CutPrefix(line, "n"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no working directory for pid %d", pid)
+}
+
+// envNamePattern matches the start of a NAME=value word in `ps e` output
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// processEnv returns the environment of a process as NAME=value entries. It
+// reads /proc where available and falls back to `ps e` elsewhere, which
+// prints the environment after the command line.
+func processEnv(pid int) ([]string, error) {
+	if data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/environ"); err == nil {
+		return splitNul(data), nil
+	}
+
+	command, err := exec.Command("ps", "-ww", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return nil, err
+	}
+	withEnv, err := exec.Command("ps", "eww", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parsePsEnv(strings.TrimSpace(string(command)), strings.TrimSpace(string(withEnv)))
+}
+
+// parsePsEnv splits the environment from `ps eww -o command=` output, given
+// the command line without it. ps separates variables with spaces, so a word
+// that does not start with NAME= continues the value before it.
+func parsePsEnv(command, withEnv string) ([]string, error) {
+	rest, ok := strings.CutPrefix(withEnv, command)
+	if !ok {
+		return nil, errors.New("command changed while reading the environment")
+	}
+	var env []string
+	for _, word := range strings.Split(strings.TrimSpace(rest), " ") {
+		switch {
+		case envNamePattern.MatchString(word):
+			env = append(env, word)
+		case len(env) > 0:
+			env[len(env)-1] += " " + word
+		}
+	}
+	if env == nil {
+		// ps leaves out the environment of processes it may not read
+		return nil, errors.New("environment not readable")
+	}
+	return env, nil
+}
+
+// projectMarkers are files and directories found at the root of a project
+var projectMarkers = []string{".git", "go.mod", "package.json", "Cargo.toml", "pyproject.toml", "Gemfile", "pom.xml"}
+
+// projectName returns the name of the project dir belongs to: the base name
+// of the nearest directory containing a project marker, or "" if there is
+// none below the home directory.
+func projectName(dir string) string {
+	home, _ := os.UserHomeDir()
+	for dir != "" && dir != "/" && dir != home {
+		for _, marker := range projectMarkers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return filepath.Base(dir)
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ""
+}
+
+// processNames returns the command names of the given processes, by PID
+func processNames(pids []int) (map[int]string, error) {
+	if len(pids) == 0 {
+		return map[int]string{}, nil
+	}
+	list := make([]string, len(pids))
+	for i, pid := range pids {
+		list[i] = strconv.Itoa(pid)
+	}
+	output, err := exec.Command("ps", "-p", strings.Join(list, ","), "-o", "pid=,comm=").Output()
+	if err != nil && len(output) == 0 {
+		return nil, err
+	}
+	return parsePsNames(string(output)), nil
+}
+
+// parsePsNames parses `ps -o pid=,comm=` output into a PID -> name map.
+// Some systems print the full path of the executable, so only its base name is kept.
+func parsePsNames(output string) map[int]string {
+	names := make(map[int]string)
+	for _, line := range strings.Split(output, "\n") {
+		pidStr, comm, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			continue
+		}
+		names[pid] = filepath.Base(strings.TrimSpace(comm))
+	}
+	return names
+}
+
+// countOpenFiles returns how many files a process has open. It reads /proc
+// where available and falls back to lsof elsewhere.
+func countOpenFiles(pid int) (int, error) {
+	if entries, err := os.ReadDir("/proc/" + strconv.Itoa(pid) + "/fd"); err == nil {
+		return len(entries), nil
+	}
+
+	// -Ff prints every file descriptor on a line starting with "f"
+	output, err := exec.Command("lsof", "-p", strconv.Itoa(pid), "-Ff").Output()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "f") {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// queryFields lists the fields a query term can be qualified with
+var queryFields = []string{"port", "user", "name", "cmd", "addr"}
+
+// query is a parsed search query. A nil query matches every process.
+//
+// A query is a list of terms that must all match. A term is a bare word,
+// matched against the process name and command (or a port, if numeric), or
+// a field-qualified value such as port:3000, port:3000-3999, user:root,
+// name:node, cmd:/vite|next/ or addr:127.0.0.1. Text values match as
+// case-insensitive substrings, or as regular expressions when written
+// between slashes. Terms can be negated with a leading -, combined with
+// AND and OR, and grouped with parentheses. AND binds tighter than OR.
+type query struct {
+	root queryNode
+}
+
+// queryNode is a term or combination of terms in a parsed query
+type queryNode interface {
+	matches(p Process) bool
+}
+
+// matches reports whether the process satisfies the query
+func (q *query) matches(p Process) bool {
+	return q == nil || q.root.matches(p)
+}
+
+// parseQuery parses a search query. An empty query parses to nil.
+func parseQuery(s string) (*query, error) {
+	tokens, err := tokenizeQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	p := &queryParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok != "" {
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+	return &query{root: root}, nil
+}
+
+// parseAnyQuery parses several queries into one that matches a process
+// matching any of them, as used for lists of CLI filter arguments
+func parseAnyQuery(filters []string) (*query, error) {
+	var alternatives orNode
+	for _, f := range filters {
+		q, err := parseQuery(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %w", f, err)
+		}
+		if q == nil {
+			return nil, nil
+		}
+		alternatives = append(alternatives, q.root)
+	}
+	if len(alternatives) == 0 {
+		return nil, nil
+	}
+	return &query{root: alternatives}, nil
+}
+
+// tokenizeQuery splits a query into words and parentheses. Field values
+// between slashes are kept whole, even if they contain spaces.
+func tokenizeQuery(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '-' && i+1 < len(s) && s[i+1] == '(':
+			tokens = append(tokens, "-")
+			i++
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t()", rune(s[i])) {
+				// A field value starting with a slash runs to the closing slash
+				if s[i] == '/' && i > start && s[i-1] == ':' {
+					end, err := regexpEnd(s, i)
+					if err != nil {
+						return nil, err
+					}
+					i = end
+					continue
+				}
+				i++
+			}
+			tokens = append(tokens, s[start:i])
+		}
+	}
+	return tokens, nil
+}
+
+// regexpEnd returns the index just past the slash closing the regular
+// expression that starts with the slash at s[start]
+func regexpEnd(s string, start int) (int, error) {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '/':
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated regular expression %q", s[start:])
+}
+
+// queryParser is a recursive descent parser over query tokens
+type queryParser struct {
+	tokens []string
+	pos    int
+}
+
+// peek returns the next token without consuming it, or "" at the end
+func (p *queryParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// next consumes and returns the next token, or "" at the end
+func (p *queryParser) next() string {
+	tok := p.peek()
+	if tok != "" {
+		p.pos++
+	}
+	return tok
+}
+
+// parseOr parses terms joined by OR
+func (p *queryParser) parseOr() (queryNode, error) {
+	var alternatives orNode
+	for {
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, node)
+		if p.peek() != "OR" {
+			break
+		}
+		p.next()
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+	return alternatives, nil
+}
+
+// parseAnd parses terms joined by AND or simply listed one after another
+func (p *queryParser) parseAnd() (queryNode, error) {
+	var terms andNode
+	for {
+		switch p.peek() {
+		case "", ")", "OR":
+			if len(terms) == 0 {
+				return nil, errors.New(p.missingTermMessage())
+			}
+			if len(terms) == 1 {
+				return terms[0], nil
+			}
+			return terms, nil
+		case "AND":
+			if len(terms) == 0 {
+				return nil, errors.New("AND needs a term before it")
+			}
+			p.next()
+			if tok := p.peek(); tok == "" || tok == ")" || tok == "OR" || tok == "AND" {
+				return nil, errors.New("AND needs a term after it")
+			}
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, node)
+	}
+}
+
+// missingTermMessage explains why a term was expected where there is none
+func (p *queryParser) missingTermMessage() string {
+	switch p.peek() {
+	case "OR":
+		return "OR needs a term on both sides"
+	case ")":
+		return "empty parentheses"
+	}
+	if p.pos > 0 && p.tokens[p.pos-1] == "OR" {
+		return "OR needs a term on both sides"
+	}
+	return "expected a search term"
+}
+
+// parseUnary parses a negated term, a parenthesized group or a single term
+func (p *queryParser) parseUnary() (queryNode, error) {
+	tok := p.next()
+	switch {
+	case tok == "":
+		return nil, errors.New("expected a search term after -")
+	case tok == "-":
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	case tok == "(":
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, errors.New("missing closing parenthesis")
+		}
+		return node, nil
+	case tok == ")":
+		return nil, errors.New("unexpected closing parenthesis")
+	case strings.HasPrefix(tok, "-"):
+		node, err := parseTerm(tok[1:])
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	}
+	return parseTerm(tok)
+}
+
+// parseTerm parses a bare word or a field:value term
+func parseTerm(tok string) (queryNode, error) {
+	field, value, qualified := strings.Cut(tok, ":")
+	if !qualified || !isQueryField(field) {
+		if qualified && isFieldName(field) {
+			return nil, fmt.Errorf("unknown field %q (use %s)", field, strings.Join(queryFields, ", "))
+		}
+		return parseBareTerm(tok)
+	}
+	if value == "" {
+		return nil, fmt.Errorf("missing value for %s:", field)
+	}
+
+	switch field {
+	case "port":
+		r, err := parsePortRange(value)
+		if err != nil {
+			return nil, err
+		}
+		return portTerm{r}, nil
+	case "addr":
+		return addrTerm{value}, nil
+	}
+	m, err := newTextMatcher(value)
+	if err != nil {
+		return nil, err
+	}
+	switch field {
+	case "user":
+		return textTerm{m, func(p Process) []string { return []string{p.User} }}, nil
+	case "name":
+		return textTerm{m, func(p Process) []string { return []string{p.Name} }}, nil
+	}
+	return textTerm{m, func(p Process) []string { return []string{p.Command} }}, nil
+}
+
+// parseBareTerm parses a word without a field. Numbers match a port exactly;
+// anything else matches the process name or command.
+func parseBareTerm(tok string) (queryNode, error) {
+	if port, err := strconv.Atoi(tok); err == nil {
+		return portTerm{portRange{lo: port, hi: port}}, nil
+	}
+	m, err := newTextMatcher(tok)
+	if err != nil {
+		return nil, err
+	}
+	return textTerm{m, func(p Process) []string { return []string{p.Name, p.Command} }}, nil
+}
+
+// isQueryField reports whether name is one of queryFields
+func isQueryField(name string) bool {
+	return slices.Contains(queryFields, name)
+}
+
+// isFieldName reports whether s looks like a misspelt field name rather than
+// part of a value, so "prot:3000" is reported as a typo while "nginx:" and
+// "localhost:3000" are searched for as they are
+func isFieldName(s string) bool {
+	for _, field := range queryFields {
+		if editDistance(s, field) <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance returns the number of single character insertions, deletions,
+// substitutions and adjacent transpositions needed to turn a into b
+func editDistance(a, b string) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// andNode matches when all of its terms match
+type andNode []queryNode
+
+func (n andNode) matches(p Process) bool {
+	for _, term := range n {
+		if !term.matches(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// orNode matches when any of its terms match
+type orNode []queryNode
+
+func (n orNode) matches(p Process) bool {
+	for _, term := range n {
+		if term.matches(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// notNode matches when its term does not
+type notNode struct {
+	node queryNode
+}
+
+func (n notNode) matches(p Process) bool {
+	return !n.node.matches(p)
+}
+
+// portTerm matches processes listening on a port in a range
+type portTerm struct {
+	ports portRange
+}
+
+func (t portTerm) matches(p Process) bool {
+	for _, port := range p.Ports {
+		if t.ports.contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
+// addrTerm matches processes with a listener bound to an address. The
+// wildcard addresses 0.0.0.0 and :: also match listeners lsof reports as "*".
+type addrTerm struct {
+	addr string
+}
+
+func (t addrTerm) matches(p Process) bool {
+	for _, l := range p.Listeners {
+		switch {
+		case l.Address == t.addr:
+			return true
+		case l.Address == "*" && t.addr == "0.0.0.0" && l.Protocol == "tcp4":
+			return true
+		case l.Address == "*" && t.addr == "::" && l.Protocol == "tcp6":
+			return true
+		}
+	}
+	return false
+}
+
+// textMatcher matches text as a case-insensitive substring or regular expression
+type textMatcher struct {
+	substr string         // lowercased, when re is nil
+	re     *regexp.Regexp // set for /regexp/ values
+}
+
+// newTextMatcher returns a matcher for value, a plain string or a /regexp/
+func newTextMatcher(value string) (textMatcher, error) {
+	if len(value) >= 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+		re, err := regexp.Compile("(?i)" + value[1:len(value)-1])
+		if err != nil {
+			return textMatcher{}, fmt.Errorf("invalid regular expression %s: %w", value, err)
+		}
+		return textMatcher{re: re}, nil
+	}
+	return textMatcher{substr: strings.ToLower(value)}, nil
+}
+
+// match reports whether s matches
+func (m textMatcher) match(s string) bool {
+	if m.re != nil {
+		return m.re.MatchString(s)
+	}
+	return strings.Contains(strings.ToLower(s), m.substr)
+}
+
+// textTerm matches processes with any of the selected strings matching
+type textTerm struct {
+	matcher textMatcher
+	fields  func(p Process) []string
+}
+
+func (t textTerm) matches(p Process) bool {
+	for _, s := range t.fields(p) {
+		if t.matcher.match(s) {
+			return true
+		}
+	}
+	return false
+}
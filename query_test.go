@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	processes := []Process{
+		{PID: 1, Ports: []int{3000}, Name: "node", User: "alice", Command: "node node_modules/.bin/vite",
+			Listeners: []Listener{{Address: "127.0.0.1", Port: 3000, Protocol: "tcp4"}}},
+		{PID: 2, Ports: []int{8030}, Name: "nginx", User: "root", Command: "nginx: master process",
+			Listeners: []Listener{{Address: "*", Port: 8030, Protocol: "tcp4"}}},
+		{PID: 3, Ports: []int{3001, 9229}, Name: "node", User: "root", Command: "node next dev",
+			Listeners: []Listener{{Address: "*", Port: 3001, Protocol: "tcp6"}}},
+		{PID: 4, Ports: []int{5432}, Name: "postgres", User: "postgres", Command: "postgres -D /var/lib/pg30"},
+	}
+
+	tests := []struct {
+		query string
+		want  []int // PIDs that match
+	}{
+		{"", []int{1, 2, 3, 4}},
+		{"30", nil},
+		{"3000", []int{1}},
+		{"NODE", []int{1, 3}},
+		{"port:3000", []int{1}},
+		{"port:3000-3999", []int{1, 3}},
+		{"port:9229", []int{3}},
+		{"user:root", []int{2, 3}},
+		{"name:node user:root", []int{3}},
+		{"name:node AND user:root", []int{3}},
+		{"user:alice OR user:postgres", []int{1, 4}},
+		{"user:root name:node OR port:5432", []int{3, 4}},
+		{"cmd:/vite|next/", []int{1, 3}},
+		{"cmd:/^postgres -d/", []int{4}},
+		{"cmd:/node (next|vite)/", []int{3}},
+		{"-name:node", []int{2, 4}},
+		{"-user:root -port:5432", []int{1}},
+		{"-(user:root OR user:alice)", []int{4}},
+		{"(name:nginx OR name:postgres) -port:8030", []int{4}},
+		{"addr:127.0.0.1", []int{1}},
+		{"addr:0.0.0.0", []int{2}},
+		{"addr:::", []int{3}},
+		{"addr:*", []int{2, 3}},
+		{"pg30", []int{4}},
+		{"nginx:", []int{2}},
+		{"localhost:3000", nil},
+		{"node:internal", nil},
+		{"http://localhost", nil},
+	}
+
+	for _, tt := range tests {
+		q, err := parseQuery(tt.query)
+		if err != nil {
+			t.Errorf("parseQuery(%q) returned error: %v", tt.query, err)
+			continue
+		}
+		var got []int
+		for _, p := range processes {
+			if q.matches(p) {
+				got = append(got, p.PID)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("query %q matched %v, expected %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"prot:3000", `unknown field "prot"`},
+		{"usr:root", `unknown field "usr"`},
+		{"port:abc", "invalid port range"},
+		{"port:4000-3000", "start is after end"},
+		{"port:", "missing value for port:"},
+		{"cmd:/vite", "unterminated regular expression"},
+		{"cmd:/(/", "invalid regular expression"},
+		{"node OR", "OR needs a term on both sides"},
+		{"OR node", "OR needs a term on both sides"},
+		{"AND node", "AND needs a term before it"},
+		{"node AND", "AND needs a term after it"},
+		{"(node", "missing closing parenthesis"},
+		{"node)", `unexpected ")"`},
+		{"()", "empty parentheses"},
+		{"node -", "expected a search term after -"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseQuery(tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseQuery(%q) error = %v, expected it to contain %q", tt.query, err, tt.want)
+		}
+	}
+}
+
+func TestParseAnyQuery(t *testing.T) {
+	q, err := parseAnyQuery([]string{"port:3000", "name:nginx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.matches(Process{Ports: []int{3000}}) || !q.matches(Process{Name: "nginx"}) || q.matches(Process{Name: "node"}) {
+		t.Error("expected a process matching any filter to match")
+	}
+
+	if _, err := parseAnyQuery([]string{"node", "port:x"}); err == nil || !strings.Contains(err.Error(), `invalid filter "port:x"`) {
+		t.Errorf("expected the bad filter to be named, got %v", err)
+	}
+}
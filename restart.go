@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// MaxRecentKills is how many killed processes the TUI remembers for relaunching
+const MaxRecentKills = 10
+
+// launchSpec is what is needed to start a process again after killing it
+type launchSpec struct {
+	Argv      []string
+	Cwd       string
+	Env       []string // nil to inherit portsweep's environment
+	SplitArgv bool     // whether Argv was split from the command line on spaces, as /proc is missing
+	Ports     []int
+	Label     string // formatted command, for display
+	KilledAt  time.Time
+}
+
+// splitArgvWarning explains what restarting a process without /proc changes
+const splitArgvWarning = "its arguments are split from the command line on spaces, so any argument containing a space is passed as several"
+
+// captureLaunchSpec records how a process was started. On Linux the argv,
+// working directory and environment come from /proc. Elsewhere the argv is
+// split from the command line and the environment is read with ps.
+func captureLaunchSpec(p Process) (launchSpec, error) {
+	spec := launchSpec{Ports: p.Ports, Label: processLabel(p)}
+
+	if data, err := os.ReadFile("/proc/" + strconv.Itoa(p.PID) + "/cmdline"); err == nil {
+		spec.Argv = splitNul(data)
+	} else {
+		spec.Argv = strings.Fields(p.Command)
+		spec.SplitArgv = true
+	}
+	if len(spec.Argv) == 0 {
+		return launchSpec{}, fmt.Errorf("no command line for pid %d", p.PID)
+	}
+	if env, err := processEnv(p.PID); err == nil {
+		spec.Env = env
+	}
+
+	cwd, err := processCwd(p.PID)
+	if err != nil {
+		return launchSpec{}, fmt.Errorf("working directory of pid %d: %w", p.PID, err)
+	}
+	spec.Cwd = cwd
+	return spec, nil
+}
+
+// hasExactArgv reports whether a process's argv can be read as it was
+// started, rather than split from its command line
+func hasExactArgv(pid int) bool {
+	_, err := os.Stat("/proc/" + strconv.Itoa(pid) + "/cmdline")
+	return err == nil
+}
+
+// splitNul splits NUL-terminated strings as found in /proc/<pid>/cmdline
+func splitNul(data []byte) []string {
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(string(data), "\x00")
+}
+
+// relaunch starts the process described by spec in a new session, detached
+// from portsweep, with its output appended to a log file. It returns the new
+// PID and the log path.
+func relaunch(spec launchSpec) (int, string, error) {
+	logPath, err := relaunchLogPath(spec)
+	if err != nil {
+		return 0, "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return 0, "", err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, "", err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(spec.Argv[0], spec.Argv[1:]...)
+	cmd.Dir = spec.Cwd
+	cmd.Env = spec.Env
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return 0, "", err
+	}
+	pid := cmd.Process.Pid
+	// Reap the process if it exits while portsweep runs; if portsweep exits
+	// first, it is reparented and reaped by init
+	go cmd.Wait()
+	return pid, logPath, nil
+}
+
+// relaunchLogPath returns the log file for a relaunched process, named after
+// its command and port so repeated restarts are easy to find.
+func relaunchLogPath(spec launchSpec) (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(spec.Argv[0])
+	if len(spec.Ports) > 0 {
+		name += "-" + strconv.Itoa(spec.Ports[0])
+	}
+	return filepath.Join(dir, "logs", name+".log"), nil
+}
+
+// restartProcess kills a process, waits until all of its ports are released
+// and relaunches it with the same argv, working directory and environment.
+func restartProcess(scanner PortScanner, killer ProcessKiller, p Process, timeout time.Duration) (int, string, error) {
+	spec, err := captureLaunchSpec(p)
+	if err != nil {
+		return 0, "", err
+	}
+	if err := sendKill(killer, killTarget{Process: p}); !killSent(err) {
+		return 0, "", fmt.Errorf("killing process %d: %w", p.PID, err)
+	} else if err != nil {
+		eprintf("process %d: %v", p.PID, err)
+	}
+	return relaunchWhenFree(scanner, spec, timeout)
+}
+
+// relaunchWhenFree waits until the ports of a killed process are released and relaunches it
+func relaunchWhenFree(scanner PortScanner, spec launchSpec, timeout time.Duration) (int, string, error) {
+	for _, port := range spec.Ports {
+		if err := waitForRelease(scanner, port, timeout); err != nil {
+			return 0, "", err
+		}
+	}
+	return relaunch(spec)
+}
+
+// restartOptions holds the flags for the restart subcommand
+type restartOptions struct {
+	timeout time.Duration
+	dryRun  bool
+}
+
+// newRestartFlags returns the flag set for the restart subcommand bound to opts
+func newRestartFlags(opts *restartOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("restart", flag.ContinueOnError)
+	fs.DurationVar(&opts.timeout, "timeout", DefaultReleaseTimeout, "how long to wait for the port to be released")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "report what would be restarted without sending signals")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: portsweep restart [--timeout 10s] [--dry-run] <port>")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Kill the process listening on the port, wait until the port is free and start it")
+		fmt.Fprintln(fs.Output(), "again, detached, with the same command, working directory and environment.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runRestart implements `portsweep restart`
+func runRestart(args []string) int {
+	opts := restartOptions{}
+	fs := newRestartFlags(&opts)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	port, err := parsePortNumber(fs.Arg(0))
+	if err != nil {
+		eprintf("%v", err)
+		return 2
+	}
+
+	holders, err := holdersOf(defaultScanner, port)
+	if err != nil {
+		eprintf("scanning ports: %v", err)
+		return 1
+	}
+	if len(holders) == 0 {
+		eprintf("nothing is listening on port %d", port)
+		return 1
+	}
+
+	// Workers forked by a server share its socket; restarting the server is enough
+	if table, err := readProcessTable(); err == nil {
+		holders = topLevelHolders(holders, table)
+	}
+
+	if opts.dryRun {
+		return rehearseRestart(holders)
+	}
+
+	killer := newKiller("restart")
+	code := 0
+	for _, p := range holders {
+		if !hasExactArgv(p.PID) {
+			eprintf("warning: restarting %s (pid %d): %s", processLabel(p), p.PID, splitArgvWarning)
+		}
+		pid, logPath, err := restartProcess(defaultScanner, killer, p, opts.timeout)
+		if err != nil {
+			eprintf("restarting %s (pid %d): %v", processLabel(p), p.PID, err)
+			code = 1
+			continue
+		}
+		eprintf("restarted %s as pid %d, logging to %s", processLabel(p), pid, logPath)
+	}
+	return code
+}
+
+// rehearseRestart reports how each holder would be killed and relaunched,
+// checking that it can be, without signalling it
+func rehearseRestart(holders []Process) int {
+	killer := newDryRunKiller("restart")
+	code := 0
+	for _, p := range holders {
+		spec, err := captureLaunchSpec(p)
+		if err != nil {
+			eprintf("restarting %s (pid %d): %v", processLabel(p), p.PID, err)
+			code = 1
+			continue
+		}
+		eprintf("would kill %s (pid %d) with %s and run %s in %s (dry run)", processLabel(p), p.PID, killDescription(killer, p), strings.Join(spec.Argv, " "), spec.Cwd)
+		if spec.SplitArgv {
+			eprintf("warning: %s", splitArgvWarning)
+		}
+	}
+	return code
+}
+
+// topLevelHolders drops the processes whose parent is also one of the holders
+func topLevelHolders(holders []Process, table map[int]procInfo) []Process {
+	pids := make(map[int]bool)
+	for _, p := range holders {
+		pids[p.PID] = true
+	}
+	var result []Process
+	for _, p := range holders {
+		if info, ok := table[p.PID]; ok && pids[info.PPID] {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result
+}
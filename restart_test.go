@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSplitNul(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"\x00", nil},
+		{"vite\x00", []string{"vite"}},
+		{"node\x00server.js\x00--port\x003000\x00", []string{"node", "server.js", "--port", "3000"}},
+		{"a b\x00\x00c\x00", []string{"a b", "", "c"}},
+	}
+
+	for _, tt := range tests {
+		if got := splitNul([]byte(tt.input)); !slices.Equal(got, tt.want) {
+			t.Errorf("splitNul(%q) = %q, expected %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTopLevelHolders(t *testing.T) {
+	holders := []Process{{PID: 10}, {PID: 11}, {PID: 12}, {PID: 20}}
+	table := map[int]procInfo{
+		10: {PID: 10, PPID: 1},
+		11: {PID: 11, PPID: 10}, // worker of 10
+		12: {PID: 12, PPID: 10}, // worker of 10
+		20: {PID: 20, PPID: 1},
+	}
+
+	var got []int
+	for _, p := range topLevelHolders(holders, table) {
+		got = append(got, p.PID)
+	}
+	if !slices.Equal(got, []int{10, 20}) {
+		t.Errorf("expected PIDs [10 20], got %v", got)
+	}
+}
+
+func TestCaptureLaunchSpec(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("argv and environment are read from /proc")
+	}
+	t.Setenv("PORTSWEEP_TEST_MARKER", "1")
+
+	spec, err := captureLaunchSpec(Process{PID: os.Getpid(), Ports: []int{3000}, Name: "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(spec.Argv, os.Args) {
+		t.Errorf("argv = %q, expected %q", spec.Argv, os.Args)
+	}
+	if wd, _ := os.Getwd(); spec.Cwd != wd {
+		t.Errorf("cwd = %q, expected %q", spec.Cwd, wd)
+	}
+	// /proc/<pid>/environ holds the environment the process started with
+	if len(spec.Env) == 0 {
+		t.Error("expected the environment to be captured")
+	}
+}
+
+func TestRelaunch(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	spec := launchSpec{
+		Argv:  []string{"sh", "-c", `touch started && echo "$GREETING"`},
+		Cwd:   dir,
+		Env:   []string{"GREETING=hello", "PATH=" + os.Getenv("PATH")},
+		Ports: []int{3000},
+	}
+	pid, logPath, err := relaunch(spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pid <= 0 || filepath.Base(logPath) != "sh-3000.log" {
+		t.Errorf("unexpected pid %d and log %q", pid, logPath)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(logPath)
+		if strings.TrimSpace(string(data)) == "hello" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("log = %q, expected the environment to be passed", data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "started")); err != nil {
+		t.Errorf("expected the command to run in %s: %v", dir, err)
+	}
+
+	// The exited process is reaped rather than left as a zombie
+	for {
+		err := syscall.Kill(pid, 0)
+		if errors.Is(err, syscall.ESRCH) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected pid %d to be reaped, kill(0) = %v", pid, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Configuration for the run subcommand
+const (
+	// DefaultPortRange is the range free ports are picked from
+	DefaultPortRange = "4000-4999"
+
+	// DefaultListenTimeout is how long to wait for the command to start listening
+	DefaultListenTimeout = 30 * time.Second
+
+	// ListenPollInterval is how often the scanner is polled while waiting for the command to listen
+	ListenPollInterval = 500 * time.Millisecond
+
+	// portPlaceholder is replaced with the chosen port in command arguments
+	portPlaceholder = "{port}"
+)
+
+// runOptions holds the flags for the run subcommand
+type runOptions struct {
+	env           string
+	ports         portRange
+	listenTimeout time.Duration
+}
+
+// newRunFlags returns the flag set for the run subcommand bound to opts
+func newRunFlags(opts *runOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.StringVar(&opts.env, "env", "PORT", "environment variable to export the port in (empty to disable)")
+	fs.Func("range", "range of ports to pick from (default "+DefaultPortRange+")", func(s string) error {
+		r, err := parsePortRange(s)
+		if err != nil {
+			return err
+		}
+		opts.ports = r
+		return nil
+	})
+	fs.DurationVar(&opts.listenTimeout, "listen-timeout", DefaultListenTimeout, "how long to wait for the command to listen on the port (0 to skip the check)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: portsweep run [--env PORT] [--range 4000-4999] -- <command> [args...]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Pick a free port, export it in an environment variable and replace "+portPlaceholder+" in the")
+		fmt.Fprintln(fs.Output(), "arguments with it, then run the command and confirm that it starts listening on the port.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runRun implements `portsweep run`: it runs a command with a free port injected
+func runRun(args []string) int {
+	opts := runOptions{}
+	opts.ports, _ = parsePortRange(DefaultPortRange)
+	fs := newRunFlags(&opts)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	argv := fs.Args()
+	if len(argv) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	port, err := pickFreePort(defaultScanner, opts.ports, probePort)
+	if err != nil {
+		eprintf("%v", err)
+		return 1
+	}
+
+	var env []string
+	if opts.env != "" {
+		env = append(env, opts.env+"="+strconv.Itoa(port))
+		eprintf("using port %d (%s=%d)", port, opts.env, port)
+	} else {
+		eprintf("using port %d", port)
+	}
+
+	c, err := startChild(substitutePort(argv, port), env, nil)
+	if err != nil {
+		eprintf("%v", err)
+		return 127
+	}
+
+	done := make(chan struct{})
+	if opts.listenTimeout > 0 {
+		go func() {
+			if msg := awaitListener(defaultScanner, port, c.PID(), opts.listenTimeout, done); msg != "" {
+				eprintf("%s", msg)
+			}
+		}()
+	}
+
+	code := c.Wait()
+	close(done)
+	return code
+}
+
+// substitutePort replaces the port placeholder in every argument
+func substitutePort(argv []string, port int) []string {
+	result := make([]string, len(argv))
+	for i, arg := range argv {
+		result[i] = strings.ReplaceAll(arg, portPlaceholder, strconv.Itoa(port))
+	}
+	return result
+}
+
+// pickFreePort returns a port in the range that no scanned process listens on
+// and that probe confirms can be bound. The search starts at a random offset
+// so parallel invocations are unlikely to race for the same port.
+func pickFreePort(scanner PortScanner, r portRange, probe func(port int) bool) (int, error) {
+	processes, err := scanner.GetListeningPorts()
+	if err != nil {
+		return 0, fmt.Errorf("scanning ports: %w", err)
+	}
+	held := make(map[int]bool)
+	for _, p := range processes {
+		for _, port := range p.Ports {
+			held[port] = true
+		}
+	}
+
+	size := r.hi - r.lo + 1
+	start := rand.IntN(size)
+	for i := 0; i < size; i++ {
+		port := r.lo + (start+i)%size
+		if !held[port] && probe(port) {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free port in range %s", r)
+}
+
+// probePort reports whether the port can currently be bound on all interfaces
+func probePort(port int) bool {
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
+
+// errStopped is returned by awaitListener when the command exits before listening
+var errStopped = errors.New("stopped")
+
+// awaitListener polls the scanner until the port is held or the timeout
+// expires, and returns a message describing the outcome. It returns an empty
+// message when done is closed first, since the command exited on its own.
+func awaitListener(scanner PortScanner, port, pid int, timeout time.Duration, done <-chan struct{}) string {
+	holder, err := pollForListener(scanner, port, timeout, done)
+	if errors.Is(err, errStopped) {
+		return ""
+	}
+	if err != nil {
+		return fmt.Sprintf("warning: %v", err)
+	}
+
+	label := fmt.Sprintf("%s (pid %d)", processLabel(holder), holder.PID)
+	if table, err := readProcessTable(); err == nil && !isDescendant(table, holder.PID, pid) {
+		return fmt.Sprintf("warning: port %d was taken by %s, not by the command", port, label)
+	}
+	return fmt.Sprintf("%s listening on port %d", label, port)
+}
+
+// pollForListener waits until some process listens on the port and returns it
+func pollForListener(scanner PortScanner, port int, timeout time.Duration, done <-chan struct{}) (Process, error) {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(ListenPollInterval)
+	defer ticker.Stop()
+
+	for {
+		holders, err := holdersOf(scanner, port)
+		if err != nil {
+			return Process{}, fmt.Errorf("scanning ports: %w", err)
+		}
+		if len(holders) > 0 {
+			return holders[0], nil
+		}
+
+		select {
+		case <-done:
+			return Process{}, errStopped
+		case <-deadline:
+			return Process{}, fmt.Errorf("nothing is listening on port %d after %s", port, timeout)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPickFreePort(t *testing.T) {
+	scanner := &MockScanner{
+		Processes: []Process{
+			{PID: 1, Ports: []int{4000, 4001}},
+			{PID: 2, Ports: []int{4003}},
+		},
+	}
+	r := portRange{4000, 4004}
+	allFree := func(int) bool { return true }
+
+	for i := 0; i < 20; i++ {
+		port, err := pickFreePort(scanner, r, allFree)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if port != 4002 && port != 4004 {
+			t.Fatalf("picked held or out of range port %d", port)
+		}
+	}
+
+	// The probe rules out ports the scanner cannot see
+	port, err := pickFreePort(scanner, r, func(port int) bool { return port != 4004 })
+	if err != nil || port != 4002 {
+		t.Errorf("expected 4002, got %d (err %v)", port, err)
+	}
+
+	if _, err := pickFreePort(scanner, portRange{4000, 4001}, allFree); err == nil {
+		t.Error("expected error when the range is exhausted")
+	}
+}
+
+func TestSubstitutePort(t *testing.T) {
+	got := substitutePort([]string{"vite", "--port", "{port}", "--url=http://localhost:{port}/"}, 4123)
+	want := []string{"vite", "--port", "4123", "--url=http://localhost:4123/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("substitutePort() = %v, expected %v", got, want)
+	}
+}
+
+func TestIsDescendant(t *testing.T) {
+	table := parsePsTable(`    1     0
+  100     1
+  200   100
+  300   200
+  400     1
+`)
+
+	tests := []struct {
+		pid, ancestor int
+		expected      bool
+	}{
+		{100, 100, true},
+		{200, 100, true},
+		{300, 100, true},
+		{400, 100, false},
+		{100, 300, false},
+		{999, 100, false},
+	}
+
+	for _, tt := range tests {
+		if got := isDescendant(table, tt.pid, tt.ancestor); got != tt.expected {
+			t.Errorf("isDescendant(%d, %d) = %v, expected %v", tt.pid, tt.ancestor, got, tt.expected)
+		}
+	}
+}
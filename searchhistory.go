@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// MaxSearchHistory is how many past searches are remembered
+const MaxSearchHistory = 100
+
+// searchHistoryPath returns where past searches are kept, one per line, oldest first
+func searchHistoryPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "search_history"), nil
+}
+
+// loadSearchHistory returns the saved searches, oldest first
+func loadSearchHistory() []string {
+	path, err := searchHistoryPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var history []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			history = append(history, line)
+		}
+	}
+	return history
+}
+
+// saveSearchHistory replaces the saved searches
+func saveSearchHistory(history []string) error {
+	path, err := searchHistoryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(history, "\n")+"\n"), 0o644)
+}
+
+// addSearchHistory returns history with search moved to the end as the newest
+// entry, keeping at most MaxSearchHistory entries
+func addSearchHistory(history []string, search string) []string {
+	search = strings.TrimSpace(search)
+	if search == "" || strings.Contains(search, "\n") {
+		return history
+	}
+	history = slices.DeleteFunc(slices.Clone(history), func(s string) bool { return s == search })
+	history = append(history, search)
+	if len(history) > MaxSearchHistory {
+		history = history[len(history)-MaxSearchHistory:]
+	}
+	return history
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestAddSearchHistory(t *testing.T) {
+	tests := []struct {
+		history []string
+		search  string
+		want    []string
+	}{
+		{nil, "node", []string{"node"}},
+		{[]string{"node", "vite"}, "node", []string{"vite", "node"}},
+		{[]string{"node"}, "  ", []string{"node"}},
+		{[]string{"node"}, " vite ", []string{"node", "vite"}},
+	}
+	for _, tt := range tests {
+		if got := addSearchHistory(tt.history, tt.search); !slices.Equal(got, tt.want) {
+			t.Errorf("addSearchHistory(%v, %q) = %v, expected %v", tt.history, tt.search, got, tt.want)
+		}
+	}
+
+	var history []string
+	for i := range MaxSearchHistory + 5 {
+		history = addSearchHistory(history, strconv.Itoa(i))
+	}
+	if len(history) != MaxSearchHistory || history[0] != "5" {
+		t.Errorf("expected the oldest searches to be dropped, got %d starting with %q", len(history), history[0])
+	}
+}
+
+func TestSaveSearchHistory(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	if got := loadSearchHistory(); got != nil {
+		t.Errorf("expected no history before saving, got %v", got)
+	}
+	want := []string{"node", "port:3000-3999 -user:root"}
+	if err := saveSearchHistory(want); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadSearchHistory(); !slices.Equal(got, want) {
+		t.Errorf("loadSearchHistory() = %v, expected %v", got, want)
+	}
+}
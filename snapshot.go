@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// snapshotVersion is the current snapshot file format version
+const snapshotVersion = 1
+
+// liveSnapshot is the diff argument that compares against the current listeners
+const liveSnapshot = "live"
+
+// snapshot is a saved set of listening processes
+type snapshot struct {
+	Version   int             `json:"version"`
+	TakenAt   time.Time       `json:"taken_at"`
+	Host      string          `json:"host"`
+	Processes []snapshotEntry `json:"processes"`
+}
+
+// snapshotEntry is a process as recorded in a snapshot
+type snapshotEntry struct {
+	Process
+	FormattedCommand string `json:"formatted_command"`
+}
+
+// changeKind classifies a difference between two snapshots
+type changeKind string
+
+const (
+	changeOpened  changeKind = "opened"  // port is listening only in the newer snapshot
+	changeClosed  changeKind = "closed"  // port is listening only in the older snapshot
+	changePID     changeKind = "pid"     // port is held by a different process
+	changeCommand changeKind = "command" // same process, but its command changed
+)
+
+// portChange is a single difference between two snapshots, keyed by port
+type portChange struct {
+	Kind   changeKind
+	Port   int
+	Before *snapshotEntry // nil when opened
+	After  *snapshotEntry // nil when closed
+}
+
+// takeSnapshot scans the current listeners into a snapshot
+func takeSnapshot(scanner PortScanner) (snapshot, error) {
+	processes, err := scanner.GetListeningPorts()
+	if err != nil {
+		return snapshot{}, err
+	}
+	host, _ := os.Hostname()
+	return newSnapshot(processes, host, time.Now()), nil
+}
+
+// newSnapshot builds a snapshot from a process list, sorted by lowest port
+func newSnapshot(processes []Process, host string, takenAt time.Time) snapshot {
+	entries := make([]snapshotEntry, 0, len(processes))
+	for _, p := range processes {
+		entries = append(entries, snapshotEntry{Process: p, FormattedCommand: formatCommand(p.Command)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LowestPort() < entries[j].LowestPort()
+	})
+	return snapshot{Version: snapshotVersion, TakenAt: takenAt, Host: host, Processes: entries}
+}
+
+// readSnapshot loads a snapshot file, or scans the live listeners when name is "live"
+func readSnapshot(name string) (snapshot, error) {
+	if name == liveSnapshot {
+		return takeSnapshot(defaultScanner)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return snapshot{}, err
+	}
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return snapshot{}, fmt.Errorf("%s: not a portsweep snapshot: %w", name, err)
+	}
+	if s.Version != snapshotVersion {
+		return snapshot{}, fmt.Errorf("%s: unsupported snapshot version %d", name, s.Version)
+	}
+	return s, nil
+}
+
+// writeSnapshot saves a snapshot as indented JSON to a file, or to stdout when name is "-"
+func writeSnapshot(name string, s snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if name == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
+
+// diffSnapshots compares the listeners of two snapshots port by port.
+// Changes are returned sorted by port.
+func diffSnapshots(before, after []snapshotEntry) []portChange {
+	beforeByPort := entriesByPort(before)
+	afterByPort := entriesByPort(after)
+
+	var changes []portChange
+	for port, b := range beforeByPort {
+		a, ok := afterByPort[port]
+		switch {
+		case !ok:
+			changes = append(changes, portChange{Kind: changeClosed, Port: port, Before: b})
+		case a.PID != b.PID:
+			changes = append(changes, portChange{Kind: changePID, Port: port, Before: b, After: a})
+		case a.Command != b.Command:
+			changes = append(changes, portChange{Kind: changeCommand, Port: port, Before: b, After: a})
+		}
+	}
+	for port, a := range afterByPort {
+		if _, ok := beforeByPort[port]; !ok {
+			changes = append(changes, portChange{Kind: changeOpened, Port: port, After: a})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Port < changes[j].Port
+	})
+	return changes
+}
+
+// entriesByPort indexes snapshot entries by each port they listen on
+func entriesByPort(entries []snapshotEntry) map[int]*snapshotEntry {
+	byPort := make(map[int]*snapshotEntry)
+	for i := range entries {
+		for _, port := range entries[i].Ports {
+			byPort[port] = &entries[i]
+		}
+	}
+	return byPort
+}
+
+// entryLabel returns the formatted command of an entry, falling back to its name
+func entryLabel(e *snapshotEntry) string {
+	if e.FormattedCommand != "" {
+		return e.FormattedCommand
+	}
+	return e.Name
+}
+
+// printChanges writes a human readable list of snapshot changes
+func printChanges(w io.Writer, changes []portChange) {
+	for _, c := range changes {
+		switch c.Kind {
+		case changeOpened:
+			fmt.Fprintf(w, "+ %-6d %-30s pid %d\n", c.Port, entryLabel(c.After), c.After.PID)
+		case changeClosed:
+			fmt.Fprintf(w, "- %-6d %-30s pid %d\n", c.Port, entryLabel(c.Before), c.Before.PID)
+		case changePID:
+			fmt.Fprintf(w, "~ %-6d %-30s pid %d -> %d\n", c.Port, entryLabel(c.After), c.Before.PID, c.After.PID)
+			if c.Before.Command != c.After.Command {
+				fmt.Fprintf(w, "         command: %s -> %s\n", entryLabel(c.Before), entryLabel(c.After))
+			}
+		case changeCommand:
+			fmt.Fprintf(w, "~ %-6d %-30s pid %d\n", c.Port, entryLabel(c.After), c.After.PID)
+			fmt.Fprintf(w, "         command: %s -> %s\n", c.Before.Command, c.After.Command)
+		}
+	}
+}
+
+// runSnapshot implements `portsweep snapshot save|diff`
+func runSnapshot(args []string) int {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  portsweep snapshot save <file>         Save the current listeners (- for stdout)")
+		fmt.Fprintln(os.Stderr, "  portsweep snapshot diff <a> <b|live>   Compare two snapshots, or a snapshot with the live listeners")
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+
+	switch args[0] {
+	case "save":
+		if len(args) != 2 {
+			usage()
+			return 2
+		}
+		s, err := takeSnapshot(defaultScanner)
+		if err != nil {
+			eprintf("scanning ports: %v", err)
+			return 1
+		}
+		if err := writeSnapshot(args[1], s); err != nil {
+			eprintf("%v", err)
+			return 1
+		}
+		if args[1] != "-" {
+			eprintf("saved %s to %s", pluralize(len(s.Processes), "process", "processes"), args[1])
+		}
+		return 0
+
+	case "diff":
+		if len(args) != 3 {
+			usage()
+			return 2
+		}
+		before, err := readSnapshot(args[1])
+		if err != nil {
+			eprintf("%v", err)
+			return 1
+		}
+		after, err := readSnapshot(args[2])
+		if err != nil {
+			eprintf("%v", err)
+			return 1
+		}
+
+		fmt.Printf("--- %s (%s, %s)\n", args[1], before.Host, before.TakenAt.Format(time.DateTime))
+		fmt.Printf("+++ %s (%s, %s)\n", args[2], after.Host, after.TakenAt.Format(time.DateTime))
+		changes := diffSnapshots(before.Processes, after.Processes)
+		if len(changes) == 0 {
+			fmt.Println("no changes")
+			return 0
+		}
+		printChanges(os.Stdout, changes)
+		// Like diff(1), report differences through the exit status
+		return 1
+	}
+
+	usage()
+	return 2
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	before := newSnapshot([]Process{
+		{PID: 1, Ports: []int{3000}, Name: "node", Command: "node /Users/me/Code/web/node_modules/.bin/vite"},
+		{PID: 2, Ports: []int{5432}, Name: "postgres", Command: "/usr/bin/postgres"},
+		{PID: 3, Ports: []int{8080, 8443}, Name: "nginx", Command: "nginx: master process"},
+		{PID: 4, Ports: []int{9000}, Name: "app", Command: "app --old"},
+	}, "a", time.Now()).Processes
+	after := newSnapshot([]Process{
+		{PID: 10, Ports: []int{3000}, Name: "node", Command: "node /Users/me/Code/web/node_modules/.bin/vite"},
+		{PID: 3, Ports: []int{8080}, Name: "nginx", Command: "nginx: master process"},
+		{PID: 4, Ports: []int{9000}, Name: "app", Command: "app --new"},
+		{PID: 5, Ports: []int{6379}, Name: "redis", Command: "redis-server *:6379"},
+	}, "b", time.Now()).Processes
+
+	changes := diffSnapshots(before, after)
+
+	expected := []struct {
+		kind changeKind
+		port int
+	}{
+		{changePID, 3000},
+		{changeClosed, 5432},
+		{changeOpened, 6379},
+		{changeClosed, 8443},
+		{changeCommand, 9000},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %+v", len(expected), len(changes), changes)
+	}
+	for i, exp := range expected {
+		if changes[i].Kind != exp.kind || changes[i].Port != exp.port {
+			t.Errorf("change[%d] = %s %d, expected %s %d", i, changes[i].Kind, changes[i].Port, exp.kind, exp.port)
+		}
+	}
+
+	var out strings.Builder
+	printChanges(&out, changes)
+	if !strings.Contains(out.String(), "pid 1 -> 10") {
+		t.Errorf("expected PID change in output, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "command: app --old -> app --new") {
+		t.Errorf("expected command change in output, got:\n%s", out.String())
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snap.json")
+	takenAt := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	saved := newSnapshot([]Process{
+		{
+			PID: 1, Ports: []int{3000}, Name: "node", User: "me",
+			Command:   "node /Users/me/Code/web/node_modules/.bin/vite",
+			Listeners: []Listener{{Address: "127.0.0.1", Port: 3000, Protocol: "tcp4"}},
+		},
+	}, "box", takenAt)
+
+	if err := writeSnapshot(path, saved); err != nil {
+		t.Fatalf("writeSnapshot: %v", err)
+	}
+	loaded, err := readSnapshot(path)
+	if err != nil {
+		t.Fatalf("readSnapshot: %v", err)
+	}
+
+	if loaded.Host != "box" || !loaded.TakenAt.Equal(takenAt) || len(loaded.Processes) != 1 {
+		t.Fatalf("unexpected snapshot: %+v", loaded)
+	}
+	p := loaded.Processes[0]
+	if p.FormattedCommand != "vite (web)" {
+		t.Errorf("expected formatted command to be saved, got %q", p.FormattedCommand)
+	}
+	if len(p.Listeners) != 1 || p.Listeners[0].Address != "127.0.0.1" {
+		t.Errorf("expected listener details to be saved, got %+v", p.Listeners)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSnapshot(path); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
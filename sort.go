@@ -0,0 +1,127 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// sortColumn is a column the process list can be sorted by
+type sortColumn string
+
+const (
+	sortPort    sortColumn = "port"
+	sortPID     sortColumn = "pid"
+	sortName    sortColumn = "name"
+	sortUser    sortColumn = "user"
+	sortCommand sortColumn = "command"
+	sortUptime  sortColumn = "uptime"
+	sortMemory  sortColumn = "memory"
+)
+
+// sortColumns lists the sort columns in the order the sort key cycles through them
+var sortColumns = []sortColumn{sortPort, sortPID, sortName, sortUser, sortCommand, sortUptime, sortMemory}
+
+// sortOrder is how the process list is sorted
+type sortOrder struct {
+	Column     sortColumn `json:"column"`
+	Descending bool       `json:"descending"`
+}
+
+// defaultSortOrder lists processes by lowest port
+var defaultSortOrder = sortOrder{Column: sortPort}
+
+// next returns the order sorting ascending by the following column
+func (o sortOrder) next() sortOrder {
+	i := slices.Index(sortColumns, o.Column)
+	return sortOrder{Column: sortColumns[(i+1)%len(sortColumns)]}
+}
+
+// reversed returns the order sorting the same column the other way
+func (o sortOrder) reversed() sortOrder {
+	o.Descending = !o.Descending
+	return o
+}
+
+// indicator returns the arrow shown next to the sorted column
+func (o sortOrder) indicator() string {
+	if o.Descending {
+		return "▼"
+	}
+	return "▲"
+}
+
+// sortProcesses sorts processes in place. Uptime and memory come from the
+// process table; processes missing from it sort as the longest running and
+// using no memory. Ties are broken by lowest port.
+func sortProcesses(processes []Process, order sortOrder, table map[int]procInfo) {
+	compare := func(a, b Process) int {
+		switch order.Column {
+		case sortPID:
+			return cmp.Compare(a.PID, b.PID)
+		case sortName:
+			return cmp.Compare(a.Name, b.Name)
+		case sortUser:
+			return cmp.Compare(a.User, b.User)
+		case sortCommand:
+			return cmp.Compare(formatCommand(a.Command), formatCommand(b.Command))
+		case sortUptime:
+			// Shortest uptime first, so the most recent start comes first
+			return table[b.PID].StartTime.Compare(table[a.PID].StartTime)
+		case sortMemory:
+			return cmp.Compare(table[a.PID].RSS, table[b.PID].RSS)
+		}
+		return cmp.Compare(a.LowestPort(), b.LowestPort())
+	}
+	slices.SortStableFunc(processes, func(a, b Process) int {
+		c := compare(a, b)
+		if order.Descending {
+			c = -c
+		}
+		return cmp.Or(c, cmp.Compare(a.LowestPort(), b.LowestPort()))
+	})
+}
+
+// sortOrderPath returns where the TUI remembers its sort order between sessions
+func sortOrderPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sort.json"), nil
+}
+
+// loadSortOrder returns the saved sort order, or the default if none was saved
+func loadSortOrder() sortOrder {
+	path, err := sortOrderPath()
+	if err != nil {
+		return defaultSortOrder
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return defaultSortOrder
+	}
+	var order sortOrder
+	if json.Unmarshal(data, &order) != nil || !slices.Contains(sortColumns, order.Column) {
+		return defaultSortOrder
+	}
+	return order
+}
+
+// saveSortOrder remembers the sort order for the next session
+func saveSortOrder(order sortOrder) error {
+	path, err := sortOrderPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
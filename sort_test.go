@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortProcesses(t *testing.T) {
+	now := time.Now()
+	processes := []Process{
+		{PID: 30, Ports: []int{8080}, Name: "nginx", User: "www", Command: "nginx: master process"},
+		{PID: 10, Ports: []int{3000}, Name: "node", User: "alice", Command: "node server.js"},
+		{PID: 20, Ports: []int{5432}, Name: "postgres", User: "postgres", Command: "/usr/lib/postgresql/16/bin/postgres"},
+		{PID: 40, Ports: []int{4000}, Name: "node", User: "bob", Command: "node api.js"},
+	}
+	table := map[int]procInfo{
+		10: {PID: 10, RSS: 300, StartTime: now.Add(-time.Minute)},
+		20: {PID: 20, RSS: 100, StartTime: now.Add(-time.Hour)},
+		30: {PID: 30, RSS: 200, StartTime: now.Add(-time.Second)},
+	}
+
+	tests := []struct {
+		order sortOrder
+		want  []int // PIDs in order
+	}{
+		{sortOrder{Column: sortPort}, []int{10, 40, 20, 30}},
+		{sortOrder{Column: sortPort, Descending: true}, []int{30, 20, 40, 10}},
+		{sortOrder{Column: sortPID}, []int{10, 20, 30, 40}},
+		{sortOrder{Column: sortName}, []int{30, 10, 40, 20}},
+		{sortOrder{Column: sortName, Descending: true}, []int{20, 10, 40, 30}},
+		{sortOrder{Column: sortUser}, []int{10, 40, 20, 30}},
+		{sortOrder{Column: sortUptime}, []int{30, 10, 20, 40}},
+		{sortOrder{Column: sortMemory, Descending: true}, []int{10, 30, 20, 40}},
+	}
+
+	for _, tt := range tests {
+		sorted := append([]Process(nil), processes...)
+		sortProcesses(sorted, tt.order, table)
+		var got []int
+		for _, p := range sorted {
+			got = append(got, p.PID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sortProcesses(%+v) = %v, expected %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestSortOrderNext(t *testing.T) {
+	order := sortOrder{Column: sortPort, Descending: true}
+	for _, want := range append(sortColumns[1:], sortPort) {
+		order = order.next()
+		if order.Column != want || order.Descending {
+			t.Errorf("next() = %+v, expected %s ascending", order, want)
+		}
+	}
+}
+
+func TestSaveSortOrder(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	if got := loadSortOrder(); got != defaultSortOrder {
+		t.Errorf("expected the default order before saving, got %+v", got)
+	}
+	want := sortOrder{Column: sortMemory, Descending: true}
+	if err := saveSortOrder(want); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadSortOrder(); got != want {
+		t.Errorf("loadSortOrder() = %+v, expected %+v", got, want)
+	}
+}
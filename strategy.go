@@ -0,0 +1,455 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Shutdown strategy constants
+const (
+	// DefaultGracePeriod is how long a process gets to exit before the fallback signal
+	DefaultGracePeriod = 10 * time.Second
+
+	// ExitPollInterval is how often a process is checked while waiting for it to exit
+	ExitPollInterval = 100 * time.Millisecond
+
+	// ShutdownRequestTimeout bounds HTTP shutdown requests
+	ShutdownRequestTimeout = 5 * time.Second
+
+	// pidPlaceholder is replaced with the PID in shutdown URLs and commands
+	pidPlaceholder = "{pid}"
+)
+
+// shutdownAction is how a strategy asks a process to stop
+type shutdownAction string
+
+const (
+	actionSignal  shutdownAction = "signal"  // send a signal
+	actionHTTP    shutdownAction = "http"    // POST to a shutdown endpoint
+	actionCommand shutdownAction = "command" // run a command, e.g. docker stop
+)
+
+// shutdownStrategy describes how to stop a particular kind of process
+type shutdownStrategy struct {
+	Label    string // short name shown to the user; derived from the action if empty
+	Action   shutdownAction
+	Signal   syscall.Signal // for actionSignal
+	URL      string         // for actionHTTP; may contain {port} and {pid}
+	Command  []string       // for actionCommand; may contain {port} and {pid}
+	Grace    time.Duration  // how long to wait for the process to exit; 0 to not wait
+	Fallback syscall.Signal // sent if the process is still running after Grace; 0 for none
+}
+
+// defaultStrategy is used for processes no rule matches
+var defaultStrategy = shutdownStrategy{Action: actionSignal, Signal: syscall.SIGTERM}
+
+// describe returns a short description of the strategy, such as "SIGINT, then SIGQUIT after 30s"
+func (s shutdownStrategy) describe(p Process) string {
+	desc := s.Label
+	if desc == "" {
+		switch s.Action {
+		case actionHTTP:
+			desc = "POST " + expandTemplate(s.URL, p)
+		case actionCommand:
+			desc = strings.Join(s.Command, " ")
+		default:
+			desc = signalName(s.Signal)
+		}
+	}
+	if s.Fallback != 0 {
+		desc += fmt.Sprintf(", then %s after %s", signalName(s.Fallback), s.Grace)
+	}
+	return desc
+}
+
+// strategyRule picks a strategy for the processes it matches. Every criterion
+// that is set has to match.
+type strategyRule struct {
+	Name     string         // process name, case-insensitive
+	Command  *regexp.Regexp // matched against the full command line
+	Ports    *portRange     // matched against any listening port
+	Strategy shutdownStrategy
+}
+
+// matches reports whether the rule applies to a process
+func (r strategyRule) matches(p Process) bool {
+	if r.Name != "" && !strings.EqualFold(r.Name, p.Name) {
+		return false
+	}
+	if r.Command != nil && !r.Command.MatchString(p.Command) {
+		return false
+	}
+	if r.Ports != nil {
+		found := false
+		for _, port := range p.Ports {
+			if r.Ports.contains(port) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// builtinRules are the strategies portsweep knows without configuration
+var builtinRules = []strategyRule{
+	{
+		// Fast shutdown: roll back transactions and disconnect clients cleanly
+		Name:     "postgres",
+		Strategy: shutdownStrategy{Action: actionSignal, Signal: syscall.SIGINT, Grace: 30 * time.Second, Fallback: syscall.SIGQUIT},
+	},
+	{
+		// Graceful shutdown: finish serving open requests
+		Name:     "nginx",
+		Strategy: shutdownStrategy{Action: actionSignal, Signal: syscall.SIGQUIT, Grace: DefaultGracePeriod, Fallback: syscall.SIGTERM},
+	},
+	{
+		// Graceful shutdown: let workers drain their requests
+		Command:  regexp.MustCompile(`\bunicorn\b`),
+		Strategy: shutdownStrategy{Action: actionSignal, Signal: syscall.SIGQUIT, Grace: 30 * time.Second, Fallback: syscall.SIGTERM},
+	},
+	{
+		// Published container ports are held by Docker's proxy, so stop the container instead
+		Command: regexp.MustCompile(`\bdocker-proxy\b|com\.docker\.backend`),
+		Strategy: shutdownStrategy{
+			Label:   "docker stop",
+			Action:  actionCommand,
+			Command: []string{"sh", "-c", `ids=$(docker ps -q --filter publish={port}) && if [ -z "$ids" ]; then echo "no running container publishes port {port}" >&2; exit 1; fi && docker stop $ids`},
+		},
+	},
+}
+
+// StrategyKiller implements ProcessKiller by shutting each process down with
+// the strategy of the first matching rule, or the default strategy.
+type StrategyKiller struct {
+	Rules []strategyRule
+	// Lookup returns the name, command and ports of a PID so rules can be matched.
+	// If nil, lsof is used.
+	Lookup func(pid int) (Process, error)
+}
+
+// StrategyFor returns the strategy used to shut down a process
+func (k *StrategyKiller) StrategyFor(p Process) shutdownStrategy {
+	for _, r := range k.Rules {
+		if r.matches(p) {
+			return r.Strategy
+		}
+	}
+	return defaultStrategy
+}
+
+// Kill shuts the process down with its strategy. If the strategy has a grace
+// period, Kill waits for the process to exit and sends the fallback signal if
+// it does not.
+func (k *StrategyKiller) Kill(pid int) error {
+	lookup := k.Lookup
+	if lookup == nil {
+		lookup = lookupListener
+	}
+	p, err := lookup(pid)
+	if err != nil {
+		p = Process{PID: pid}
+	}
+	p.PID = pid
+	return k.KillTarget(killTarget{Process: p})
+}
+
+// KillTarget shuts the process down as Kill does, matching the rules against
+// the details the caller passes instead of looking them up
+func (k *StrategyKiller) KillTarget(t killTarget) error {
+	p, pid := t.Process, t.PID
+	s := k.StrategyFor(p)
+	err := runShutdownAction(s, p)
+	if s.Fallback == 0 {
+		return err
+	}
+	if err == nil && waitForExit(pid, s.Grace) {
+		return nil
+	}
+	if errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	if fallbackErr := syscall.Kill(pid, s.Fallback); fallbackErr != nil && !errors.Is(fallbackErr, syscall.ESRCH) {
+		return fallbackErr
+	}
+	if err != nil {
+		return &fallbackError{Signal: s.Fallback, Err: err}
+	}
+	return nil
+}
+
+// fallbackError reports a shutdown action that failed, after which the
+// fallback signal was sent. The process was still signalled.
+type fallbackError struct {
+	Signal syscall.Signal
+	Err    error
+}
+
+func (e *fallbackError) Error() string {
+	return fmt.Sprintf("shutdown failed, sent %s instead: %v", signalName(e.Signal), e.Err)
+}
+
+func (e *fallbackError) Unwrap() error {
+	return e.Err
+}
+
+// killSent reports whether a kill that returned err still signalled the
+// process, either directly or with the fallback signal
+func killSent(err error) bool {
+	var fallback *fallbackError
+	return err == nil || errors.As(err, &fallback)
+}
+
+// runShutdownAction performs the strategy's action against a process
+func runShutdownAction(s shutdownStrategy, p Process) error {
+	switch s.Action {
+	case actionHTTP:
+		client := http.Client{Timeout: ShutdownRequestTimeout}
+		resp, err := client.Post(expandTemplate(s.URL, p), "text/plain", nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("shutdown request failed: %s", resp.Status)
+		}
+		return nil
+	case actionCommand:
+		argv := make([]string, len(s.Command))
+		for i, arg := range s.Command {
+			argv[i] = expandTemplate(arg, p)
+		}
+		output, err := exec.Command(argv[0], argv[1:]...).CombinedOutput()
+		if err != nil {
+			if msg := strings.TrimSpace(string(output)); msg != "" {
+				return fmt.Errorf("%s: %w: %s", argv[0], err, msg)
+			}
+			return fmt.Errorf("%s: %w", argv[0], err)
+		}
+		return nil
+	default:
+		sig := s.Signal
+		if sig == 0 {
+			sig = syscall.SIGTERM
+		}
+		return syscall.Kill(p.PID, sig)
+	}
+}
+
+// expandTemplate replaces {port} with the lowest port of the process and {pid} with its PID
+func expandTemplate(s string, p Process) string {
+	s = strings.ReplaceAll(s, portPlaceholder, strconv.Itoa(p.LowestPort()))
+	return strings.ReplaceAll(s, pidPlaceholder, strconv.Itoa(p.PID))
+}
+
+// waitForExit polls until the process has exited or the timeout expires,
+// and reports whether it exited.
+func waitForExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(ExitPollInterval)
+	}
+}
+
+// strategyDescriber is implemented by killers that pick a strategy per process
+type strategyDescriber interface {
+	StrategyFor(p Process) shutdownStrategy
+}
+
+// killDescription returns how a killer will shut down a process, for prompts and logs
+func killDescription(killer ProcessKiller, p Process) string {
+	switch k := killer.(type) {
+	case *AuditKiller:
+		return killDescription(k.Killer, p)
+	case *RecordingKiller:
+		if k.Rehearsed != nil {
+			return killDescription(k.Rehearsed, p)
+		}
+	case strategyDescriber:
+		return k.StrategyFor(p).describe(p)
+	case *SignalKiller:
+		return signalName(k.Signal)
+	}
+	return signalName(syscall.SIGTERM)
+}
+
+// newKiller returns the killer used for interactive and subcommand kills:
+// shutdown strategies, recorded in the audit log. A broken strategies file
+// is reported and the built-in rules are used instead.
+func newKiller(source string) ProcessKiller {
+	k, err := loadStrategyKiller()
+	if err != nil {
+		eprintf("ignoring strategies: %v", err)
+	}
+	return auditedKiller(k, source)
+}
+
+// newDryRunKiller returns a killer that records kills instead of sending them,
+// describing them as newKiller's would be
+func newDryRunKiller(source string) *RecordingKiller {
+	return &RecordingKiller{Rehearsed: newKiller(source)}
+}
+
+// strategyConfig is the format of the strategies file
+type strategyConfig struct {
+	Rules []strategyRuleConfig `json:"rules"`
+}
+
+// strategyRuleConfig is a rule as written in the strategies file
+type strategyRuleConfig struct {
+	Name     string   `json:"name"`
+	Command  string   `json:"command"` // regular expression
+	Port     string   `json:"port"`    // port or range, e.g. "8000-8099"
+	Label    string   `json:"label"`
+	Action   string   `json:"action"` // "signal" (default), "http" or "command"
+	Signal   string   `json:"signal"`
+	URL      string   `json:"url"`
+	Run      []string `json:"run"`
+	Grace    string   `json:"grace"`
+	Fallback string   `json:"fallback"`
+}
+
+// strategiesPath returns the location of the strategies file, following the
+// XDG base directory spec
+func strategiesPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "portsweep", "strategies.json"), nil
+}
+
+// loadStrategyKiller returns a StrategyKiller with the rules from the
+// strategies file, if there is one, ahead of the built-in rules.
+func loadStrategyKiller() (*StrategyKiller, error) {
+	k := &StrategyKiller{Rules: builtinRules}
+	path, err := strategiesPath()
+	if err != nil {
+		return k, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return k, nil
+	}
+	if err != nil {
+		return k, err
+	}
+
+	rules, err := parseStrategyConfig(data)
+	if err != nil {
+		return k, fmt.Errorf("%s: %w", path, err)
+	}
+	k.Rules = append(rules, builtinRules...)
+	return k, nil
+}
+
+// parseStrategyConfig parses the rules of a strategies file
+func parseStrategyConfig(data []byte) ([]strategyRule, error) {
+	var config strategyConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	rules := make([]strategyRule, 0, len(config.Rules))
+	for i, c := range config.Rules {
+		rule, err := c.rule()
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// rule validates a configured rule and converts it
+func (c strategyRuleConfig) rule() (strategyRule, error) {
+	if c.Name == "" && c.Command == "" && c.Port == "" {
+		return strategyRule{}, errors.New("needs at least one of name, command or port")
+	}
+
+	r := strategyRule{Name: c.Name}
+	if c.Command != "" {
+		re, err := regexp.Compile(c.Command)
+		if err != nil {
+			return strategyRule{}, fmt.Errorf("command: %w", err)
+		}
+		r.Command = re
+	}
+	if c.Port != "" {
+		ports, err := parsePortRange(c.Port)
+		if err != nil {
+			return strategyRule{}, err
+		}
+		r.Ports = &ports
+	}
+
+	s := shutdownStrategy{Label: c.Label, Action: shutdownAction(c.Action)}
+	switch s.Action {
+	case "", actionSignal:
+		s.Action = actionSignal
+		s.Signal = syscall.SIGTERM
+		if c.Signal != "" {
+			sig, err := parseSignal(c.Signal)
+			if err != nil {
+				return strategyRule{}, err
+			}
+			s.Signal = sig
+		}
+	case actionHTTP:
+		if c.URL == "" {
+			return strategyRule{}, errors.New("http action needs a url")
+		}
+		s.URL = c.URL
+	case actionCommand:
+		if len(c.Run) == 0 {
+			return strategyRule{}, errors.New("command action needs run")
+		}
+		s.Command = c.Run
+	default:
+		return strategyRule{}, fmt.Errorf("unknown action %q (expected signal, http or command)", c.Action)
+	}
+
+	if c.Grace != "" {
+		grace, err := time.ParseDuration(c.Grace)
+		if err != nil || grace < 0 {
+			return strategyRule{}, fmt.Errorf("invalid grace %q", c.Grace)
+		}
+		s.Grace = grace
+	}
+	if c.Fallback != "" {
+		sig, err := parseSignal(c.Fallback)
+		if err != nil {
+			return strategyRule{}, fmt.Errorf("fallback: %w", err)
+		}
+		s.Fallback = sig
+		if s.Grace == 0 {
+			s.Grace = DefaultGracePeriod
+		}
+	}
+
+	r.Strategy = s
+	return r, nil
+}
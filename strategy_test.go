@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStrategyFor(t *testing.T) {
+	configured, err := parseStrategyConfig([]byte(`{"rules": [
+		{"port": "8000-8099", "action": "http", "url": "http://127.0.0.1:{port}/shutdown"},
+		{"name": "nginx", "command": "-c /etc/staging", "signal": "TERM"}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	killer := &StrategyKiller{Rules: append(configured, builtinRules...)}
+
+	tests := []struct {
+		name    string
+		process Process
+		want    string
+	}{
+		{"postgres", Process{Name: "postgres", Command: "/usr/lib/postgresql/16/bin/postgres -D /data"}, "SIGINT, then SIGQUIT after 30s"},
+		{"nginx", Process{Name: "nginx", Command: "nginx: master process nginx"}, "SIGQUIT, then SIGTERM after 10s"},
+		{"configured nginx", Process{Name: "nginx", Command: "nginx -c /etc/staging.conf"}, "SIGTERM"},
+		{"unicorn", Process{Name: "ruby", Command: "unicorn master -c config/unicorn.rb"}, "SIGQUIT, then SIGTERM after 30s"},
+		{"docker", Process{Name: "docker-pr", Command: "/usr/bin/docker-proxy -proto tcp -host-port 5432"}, "docker stop"},
+		{"configured port", Process{PID: 42, Name: "python3", Ports: []int{8080}}, "POST http://127.0.0.1:8080/shutdown"},
+		{"default", Process{Name: "node", Ports: []int{3000}}, "SIGTERM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := killDescription(killer, tt.process); got != tt.want {
+				t.Errorf("strategy = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStrategyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{"empty", `{}`, false},
+		{"signal with fallback", `{"rules": [{"name": "app", "signal": "INT", "fallback": "KILL"}]}`, false},
+		{"command", `{"rules": [{"port": "9000", "action": "command", "run": ["my-ctl", "stop", "{pid}"]}]}`, false},
+		{"no criteria", `{"rules": [{"signal": "INT"}]}`, true},
+		{"bad regex", `{"rules": [{"command": "("}]}`, true},
+		{"bad port", `{"rules": [{"port": "99999"}]}`, true},
+		{"bad signal", `{"rules": [{"name": "app", "signal": "NOPE"}]}`, true},
+		{"http without url", `{"rules": [{"name": "app", "action": "http"}]}`, true},
+		{"unknown action", `{"rules": [{"name": "app", "action": "pray"}]}`, true},
+		{"bad grace", `{"rules": [{"name": "app", "grace": "soon"}]}`, true},
+		{"invalid json", `{"rules": [`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseStrategyConfig([]byte(tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseStrategyConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	rules, _ := parseStrategyConfig([]byte(`{"rules": [{"name": "app", "fallback": "KILL"}]}`))
+	if s := rules[0].Strategy; s.Signal != syscall.SIGTERM || s.Grace != DefaultGracePeriod || s.Fallback != syscall.SIGKILL {
+		t.Errorf("expected SIGTERM with the default grace period before SIGKILL, got %+v", s)
+	}
+}
+
+func TestStrategyKillerFallback(t *testing.T) {
+	// A process that ignores SIGTERM only stops for the fallback signal
+	cmd := exec.Command("sh", "-c", `trap "" TERM; while :; do sleep 0.05; done`)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("starting process: %v", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	time.Sleep(100 * time.Millisecond) // let the shell install its trap
+
+	killer := &StrategyKiller{
+		Rules: []strategyRule{{
+			Name:     "stubborn",
+			Strategy: shutdownStrategy{Action: actionSignal, Signal: syscall.SIGTERM, Grace: 200 * time.Millisecond, Fallback: syscall.SIGKILL},
+		}},
+		Lookup: func(pid int) (Process, error) {
+			return Process{PID: pid, Name: "stubborn"}, nil
+		},
+	}
+	if err := killer.Kill(cmd.Process.Pid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("expected the fallback signal to stop the process")
+	}
+}
+
+func TestStrategyKillerKeepsActionError(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("starting process: %v", err)
+	}
+	defer cmd.Process.Kill()
+	go cmd.Wait()
+
+	// The shutdown command fails, so the fallback signal stops the process
+	killer := &StrategyKiller{
+		Rules: []strategyRule{{
+			Name:     "app",
+			Strategy: shutdownStrategy{Action: actionCommand, Command: []string{"false"}, Grace: time.Second, Fallback: syscall.SIGKILL},
+		}},
+	}
+	err := killer.KillTarget(killTarget{Process: Process{PID: cmd.Process.Pid, Name: "app"}})
+	var fallback *fallbackError
+	if !errors.As(err, &fallback) || fallback.Signal != syscall.SIGKILL || !killSent(err) {
+		t.Fatalf("expected the action error with the fallback signal, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "false") {
+		t.Errorf("expected the failed command in the error, got %q", err)
+	}
+}
+
+func TestDockerStrategy(t *testing.T) {
+	// A fake docker that lists the containers in $CONTAINERS and logs what it stops
+	dir := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$1\" = ps ]; then echo $CONTAINERS; exit 0; fi\necho \"$@\" >> \"$LOG\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(dir, "log")
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("LOG", log)
+
+	p := Process{PID: 1, Name: "docker-pr", Command: "/usr/bin/docker-proxy -proto tcp -host-port 5432", Ports: []int{5432}}
+	s := (&StrategyKiller{Rules: builtinRules}).StrategyFor(p)
+
+	t.Setenv("CONTAINERS", "")
+	if err := runShutdownAction(s, p); err == nil || !strings.Contains(err.Error(), "no running container publishes port 5432") {
+		t.Errorf("expected a clear error without a matching container, got %v", err)
+	}
+
+	t.Setenv("CONTAINERS", "abc123")
+	if err := runShutdownAction(s, p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(log); strings.TrimSpace(string(data)) != "stop abc123" {
+		t.Errorf("expected the container to be stopped, got %q", data)
+	}
+}
+
+func TestStrategyKillerHTTP(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+	}))
+	defer server.Close()
+
+	killer := &StrategyKiller{
+		Rules: []strategyRule{{
+			Name:     "app",
+			Strategy: shutdownStrategy{Action: actionHTTP, URL: server.URL + "/shutdown?pid={pid}"},
+		}},
+		Lookup: func(pid int) (Process, error) {
+			return Process{PID: pid, Name: "app"}, nil
+		},
+	}
+	if err := killer.Kill(4242); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := <-requests
+	if r.Method != http.MethodPost || r.URL.Path != "/shutdown" || r.URL.Query().Get("pid") != strconv.Itoa(4242) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL)
+	}
+}
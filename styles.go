@@ -25,24 +25,26 @@ var (
 
 	portStyle = lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#7DCFFF")).
-			Width(18)
+			Foreground(lipgloss.Color("#7DCFFF"))
 
 	pidStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#9ECE6A")).
-			Width(8)
+			Foreground(lipgloss.Color("#9ECE6A"))
 
 	nameStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#BB9AF7")).
-			Width(15)
+			Foreground(lipgloss.Color("#BB9AF7"))
 
 	userStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#E0AF68")).
-			Width(12)
+			Foreground(lipgloss.Color("#E0AF68"))
 
 	commandStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#737373"))
 
+	addrStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#7AA2F7"))
+
+	statStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#A9B1D6"))
+
 	cmdDetailStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#565656")).
 			Italic(true)
@@ -79,4 +81,45 @@ var (
 
 	searchFilterStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("#9ECE6A"))
+
+	fuzzyMatchStyle = lipgloss.NewStyle().
+			Bold(true).
+			Underline(true).
+			Foreground(lipgloss.Color("#FFD866"))
+
+	searchErrorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FF6B6B"))
+
+	pendingStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#1a1a1a")).
+			Background(lipgloss.Color("#E0AF68")).
+			Padding(0, 1).
+			MarginTop(1)
+
+	dryRunStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#E0AF68"))
+
+	scrollStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#626262"))
+
+	newRowStyle = lipgloss.NewStyle().
+			Background(lipgloss.Color("#1F3A1F"))
+
+	ghostStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#4A4A4A"))
+
+	detailPaneStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false).
+			BorderForeground(lipgloss.Color("#3B3B3B")).
+			PaddingLeft(1)
+
+	detailHeadingStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#7DCFFF"))
+
+	detailLabelStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#626262"))
 )
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// forwardedSignals are relayed from portsweep to the child command
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGQUIT,
+}
+
+// child is a user command started by portsweep. While it runs, termination
+// signals received by portsweep are forwarded to it.
+type child struct {
+	cmd      *exec.Cmd
+	signals  chan os.Signal
+	group    bool // whether the child leads a process group, which is signalled as a whole
+	terminal bool // whether the child's group was put in the foreground of the terminal
+}
+
+// startChild starts argv with the given extra environment variables appended
+// to portsweep's own environment. If stderr is nil the child's stderr is
+// connected directly to portsweep's.
+func startChild(argv []string, env []string, stderr io.Writer) (*child, error) {
+	return startProcess(argv, env, stderr, false)
+}
+
+// startChildGroup starts argv like startChild, in a process group of its own,
+// so that stopping it also stops what it started, such as the server behind
+// `npm run dev`. When stdin is a terminal the group is put in the foreground,
+// as a shell would, so the command can still read from and configure it.
+func startChildGroup(argv []string, env []string, stderr io.Writer) (*child, error) {
+	return startProcess(argv, env, stderr, true)
+}
+
+// startProcess starts argv, in a process group of its own if group is set
+func startProcess(argv []string, env []string, stderr io.Writer, group bool) (*child, error) {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if stderr != nil {
+		cmd.Stderr = stderr
+	}
+	cmd.Env = append(os.Environ(), env...)
+	terminal := group && isTerminal(os.Stdin)
+	if group {
+		// Ctty is the child's descriptor of the terminal, its stdin
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Foreground: terminal, Ctty: 0}
+	}
+
+	// Start listening before the child exists so no signal is lost in between
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+
+	if err := cmd.Start(); err != nil {
+		signal.Stop(signals)
+		return nil, err
+	}
+
+	c := &child{cmd: cmd, signals: signals, group: group, terminal: terminal}
+	go c.forward()
+	return c, nil
+}
+
+// forward relays signals to the child until Wait stops the notifications
+func (c *child) forward() {
+	for sig := range c.signals {
+		_ = c.signal(sig.(syscall.Signal))
+	}
+}
+
+// signal sends sig to the child, or to its whole process group if it has one
+func (c *child) signal(sig syscall.Signal) error {
+	if c.group {
+		return syscall.Kill(-c.PID(), sig)
+	}
+	return c.cmd.Process.Signal(sig)
+}
+
+// takeTerminal puts portsweep back in the foreground of the terminal, so it
+// can prompt while the child's group holds it
+func (c *child) takeTerminal() {
+	if c.terminal {
+		setForeground(syscall.Getpgrp())
+	}
+}
+
+// giveTerminal puts the child's group in the foreground of the terminal again
+func (c *child) giveTerminal() {
+	if c.terminal {
+		setForeground(c.PID())
+	}
+}
+
+// setForeground makes pgrp the foreground process group of the terminal on
+// stdin. Background processes are stopped by SIGTTOU when they do this, so it
+// is ignored meanwhile.
+func setForeground(pgrp int) {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+	_ = unix.IoctlSetPointerInt(int(os.Stdin.Fd()), unix.TIOCSPGRP, pgrp)
+}
+
+// PID returns the process ID of the child
+func (c *child) PID() int {
+	return c.cmd.Process.Pid
+}
+
+// Wait waits for the child to exit and returns its exit code. A child
+// terminated by a signal reports 128 plus the signal number, like a shell.
+func (c *child) Wait() int {
+	err := c.cmd.Wait()
+	signal.Stop(c.signals)
+	close(c.signals)
+	c.takeTerminal()
+	return exitCode(err)
+}
+
+// exitCode converts the error returned by exec.Cmd.Wait into an exit code
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return 1
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+// runChild starts argv, waits for it and returns its exit code. Failure to
+// start the command is reported on stderr with the conventional 127 status.
+func runChild(argv []string, env []string) int {
+	c, err := startChild(argv, env, nil)
+	if err != nil {
+		eprintf("%v", err)
+		return 127
+	}
+	return c.Wait()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// killRecord is the outcome of a single kill attempted from the TUI
+type killRecord struct {
+	PID      int
+	Port     int
+	Command  string // formatted command of the process
+	Err      error  // nil if the signal was delivered
+	DryRun   bool   // whether the kill was only recorded, not sent
+	Strategy string // signal or shutdown strategy used; SIGTERM if empty
+}
+
+// result describes the outcome of the kill for the exit summary
+func (r killRecord) result() string {
+	var fallback *fallbackError
+	if errors.As(r.Err, &fallback) {
+		return fmt.Sprintf("sent %s after shutdown failed: %v", signalName(fallback.Signal), fallback.Err)
+	}
+	if r.Err != nil {
+		return "failed: " + r.Err.Error()
+	}
+	strategy := r.Strategy
+	if strategy == "" {
+		strategy = signalName(defaultKiller.Signal)
+	}
+	if r.DryRun {
+		return "would send " + strategy + " (dry run)"
+	}
+	return "sent " + strategy
+}
+
+// printKillSummary writes one line per kill attempted during the session.
+// Nothing is printed if no kills were attempted.
+func printKillSummary(w io.Writer, records []killRecord) {
+	if len(records) == 0 {
+		return
+	}
+
+	signalled, failed, dryRuns := 0, 0, 0
+	for _, r := range records {
+		switch {
+		case r.DryRun:
+			dryRuns++
+		case killSent(r.Err):
+			signalled++
+		default:
+			failed++
+		}
+	}
+	if signalled == 0 && failed == 0 {
+		fmt.Fprintf(w, "portsweep: dry run, would have signalled %s\n", pluralize(dryRuns, "process", "processes"))
+	} else {
+		header := "portsweep: signalled " + pluralize(signalled, "process", "processes")
+		if failed > 0 {
+			header += fmt.Sprintf(", %d failed", failed)
+		}
+		if dryRuns > 0 {
+			header += fmt.Sprintf(", %d more in dry run", dryRuns)
+		}
+		fmt.Fprintln(w, header)
+	}
+	for _, r := range records {
+		fmt.Fprintf(w, "  %-6d pid %-8d %-30s %s\n", r.Port, r.PID, r.Command, r.result())
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// watchOptions holds the flags for the watch subcommand
+type watchOptions struct {
+	json     bool
+	interval time.Duration
+	initial  bool
+}
+
+// newWatchFlags returns the flag set for the watch subcommand bound to opts
+func newWatchFlags(opts *watchOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.BoolVar(&opts.json, "json", false, "print events as newline-delimited JSON")
+	fs.DurationVar(&opts.interval, "interval", RefreshInterval, "how often to scan for changes")
+	fs.BoolVar(&opts.initial, "initial", false, "report the listeners present at startup as open events")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: portsweep watch [--json] [--interval 2s] [--initial]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Print an event whenever a listener opens, closes or changes owner.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// watchEvent is a single change reported by watch
+type watchEvent struct {
+	Time            time.Time `json:"time"`
+	Event           string    `json:"event"` // "open", "close" or "owner"
+	Port            int       `json:"port"`
+	PID             int       `json:"pid"`
+	User            string    `json:"user"`
+	Command         string    `json:"command"`
+	PreviousPID     int       `json:"previous_pid,omitempty"`
+	PreviousCommand string    `json:"previous_command,omitempty"`
+}
+
+// runWatch implements `portsweep watch`
+func runWatch(args []string) int {
+	opts := watchOptions{}
+	fs := newWatchFlags(&opts)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 || opts.interval <= 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var previous []snapshotEntry
+	if !opts.initial {
+		s, err := takeSnapshot(defaultScanner)
+		if err != nil {
+			eprintf("scanning ports: %v", err)
+			return 1
+		}
+		previous = s.Processes
+	}
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	for {
+		s, err := takeSnapshot(defaultScanner)
+		if err != nil {
+			eprintf("scanning ports: %v", err)
+		} else {
+			for _, e := range changeEvents(diffSnapshots(previous, s.Processes), s.TakenAt) {
+				if err := printEvent(os.Stdout, e, opts.json); err != nil {
+					// The reader went away (e.g. `portsweep watch | head`)
+					return 0
+				}
+			}
+			previous = s.Processes
+		}
+		<-ticker.C
+	}
+}
+
+// changeEvents converts snapshot changes into watch events. Command changes
+// of the same process are not reported, since the listener did not change hands.
+func changeEvents(changes []portChange, at time.Time) []watchEvent {
+	var events []watchEvent
+	for _, c := range changes {
+		switch c.Kind {
+		case changeOpened:
+			events = append(events, newWatchEvent(at, "open", c.Port, c.After))
+		case changeClosed:
+			events = append(events, newWatchEvent(at, "close", c.Port, c.Before))
+		case changePID:
+			e := newWatchEvent(at, "owner", c.Port, c.After)
+			e.PreviousPID = c.Before.PID
+			e.PreviousCommand = entryLabel(c.Before)
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// newWatchEvent builds an event describing the process holding a port
+func newWatchEvent(at time.Time, event string, port int, e *snapshotEntry) watchEvent {
+	return watchEvent{
+		Time:    at,
+		Event:   event,
+		Port:    port,
+		PID:     e.PID,
+		User:    e.User,
+		Command: entryLabel(e),
+	}
+}
+
+// printEvent writes an event as a single line of text or JSON
+func printEvent(w io.Writer, e watchEvent, asJSON bool) error {
+	if asJSON {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	line := fmt.Sprintf("%s %-5s %-6d pid %-8d %-12s %s",
+		e.Time.Format(time.RFC3339), e.Event, e.Port, e.PID, e.User, e.Command)
+	if e.Event == "owner" {
+		line += fmt.Sprintf(" (was pid %d %s)", e.PreviousPID, e.PreviousCommand)
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangeEvents(t *testing.T) {
+	at := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	before := newSnapshot([]Process{
+		{PID: 1, Ports: []int{3000}, Name: "node", User: "me", Command: "node /Users/me/Code/web/node_modules/.bin/vite"},
+		{PID: 2, Ports: []int{5432}, Name: "postgres", User: "pg", Command: "/usr/bin/postgres"},
+		{PID: 3, Ports: []int{9000}, Name: "app", User: "me", Command: "app --old"},
+	}, "", at).Processes
+	after := newSnapshot([]Process{
+		{PID: 10, Ports: []int{3000}, Name: "node", User: "me", Command: "node /Users/me/Code/api/node_modules/.bin/vite"},
+		{PID: 3, Ports: []int{9000}, Name: "app", User: "me", Command: "app --new"},
+		{PID: 4, Ports: []int{6379}, Name: "redis", User: "me", Command: "redis-server"},
+	}, "", at).Processes
+
+	events := changeEvents(diffSnapshots(before, after), at)
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d: %+v", len(events), events)
+	}
+	owner := events[0]
+	if owner.Event != "owner" || owner.Port != 3000 || owner.PID != 10 || owner.PreviousPID != 1 {
+		t.Errorf("unexpected owner event: %+v", owner)
+	}
+	if owner.Command != "vite (api)" || owner.PreviousCommand != "vite (web)" {
+		t.Errorf("expected formatted commands, got %q and %q", owner.Command, owner.PreviousCommand)
+	}
+	if events[1].Event != "close" || events[1].Port != 5432 || events[1].User != "pg" {
+		t.Errorf("unexpected close event: %+v", events[1])
+	}
+	if events[2].Event != "open" || events[2].Port != 6379 {
+		t.Errorf("unexpected open event: %+v", events[2])
+	}
+}
+
+func TestPrintEventJSON(t *testing.T) {
+	e := watchEvent{
+		Time:    time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC),
+		Event:   "open",
+		Port:    3000,
+		PID:     123,
+		User:    "me",
+		Command: "vite (web)",
+	}
+
+	var out strings.Builder
+	if err := printEvent(&out, e, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 1 {
+		t.Errorf("expected exactly one line, got %q", out.String())
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(out.String()), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded["event"] != "open" || decoded["port"] != float64(3000) || decoded["command"] != "vite (web)" {
+		t.Errorf("unexpected JSON: %s", out.String())
+	}
+	if _, ok := decoded["previous_pid"]; ok {
+		t.Errorf("previous_pid should be omitted for open events: %s", out.String())
+	}
+}